@@ -95,6 +95,10 @@ func main() {
 		metricsServer.ListenAndServe()
 	}()
 
+	// Delete expired execution records and unreleased workspaces
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	go manager.RunJanitor(janitorCtx)
+
 	log.Println("Script Executor started")
 	log.Println("  - script.run (supports streaming)")
 	log.Println("Test with: grpcurl -plaintext localhost:50051 list")
@@ -109,6 +113,7 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	stopJanitor()
 	grpcServer.GracefulStop()
 	metricsServer.Shutdown(ctx)
 	if httpServer != nil {
@@ -134,6 +134,67 @@ func (ExecuteProgress_Stage) EnumDescriptor() ([]byte, []int) {
 	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{5, 0}
 }
 
+type Execution_State int32
+
+const (
+	Execution_STATE_UNSPECIFIED Execution_State = 0
+	Execution_STATE_PENDING     Execution_State = 1 // Awaiting manual approval before execution
+	Execution_STATE_RUNNING     Execution_State = 2
+	Execution_STATE_SUCCEEDED   Execution_State = 3
+	Execution_STATE_FAILED      Execution_State = 4
+	Execution_STATE_TIMEOUT     Execution_State = 5
+	Execution_STATE_CANCELLED   Execution_State = 6
+)
+
+// Enum value maps for Execution_State.
+var (
+	Execution_State_name = map[int32]string{
+		0: "STATE_UNSPECIFIED",
+		1: "STATE_PENDING",
+		2: "STATE_RUNNING",
+		3: "STATE_SUCCEEDED",
+		4: "STATE_FAILED",
+		5: "STATE_TIMEOUT",
+		6: "STATE_CANCELLED",
+	}
+	Execution_State_value = map[string]int32{
+		"STATE_UNSPECIFIED": 0,
+		"STATE_PENDING":     1,
+		"STATE_RUNNING":     2,
+		"STATE_SUCCEEDED":   3,
+		"STATE_FAILED":      4,
+		"STATE_TIMEOUT":     5,
+		"STATE_CANCELLED":   6,
+	}
+)
+
+func (x Execution_State) Enum() *Execution_State {
+	p := new(Execution_State)
+	*p = x
+	return p
+}
+
+func (x Execution_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Execution_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_executor_v1_executor_proto_enumTypes[2].Descriptor()
+}
+
+func (Execution_State) Type() protoreflect.EnumType {
+	return &file_proto_executor_v1_executor_proto_enumTypes[2]
+}
+
+func (x Execution_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Execution_State.Descriptor instead.
+func (Execution_State) EnumDescriptor() ([]byte, []int) {
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{6, 0}
+}
+
 type HealthResponse_Status int32
 
 const (
@@ -170,11 +231,11 @@ func (x HealthResponse_Status) String() string {
 }
 
 func (HealthResponse_Status) Descriptor() protoreflect.EnumDescriptor {
-	return file_proto_executor_v1_executor_proto_enumTypes[2].Descriptor()
+	return file_proto_executor_v1_executor_proto_enumTypes[3].Descriptor()
 }
 
 func (HealthResponse_Status) Type() protoreflect.EnumType {
-	return &file_proto_executor_v1_executor_proto_enumTypes[2]
+	return &file_proto_executor_v1_executor_proto_enumTypes[3]
 }
 
 func (x HealthResponse_Status) Number() protoreflect.EnumNumber {
@@ -183,7 +244,7 @@ func (x HealthResponse_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use HealthResponse_Status.Descriptor instead.
 func (HealthResponse_Status) EnumDescriptor() ([]byte, []int) {
-	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{10, 0}
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{17, 0}
 }
 
 type ExecuteRequest struct {
@@ -626,6 +687,398 @@ func (x *ExecuteProgress) GetResult() *ExecuteResponse {
 	return nil
 }
 
+type Execution struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ExecutionId string                 `protobuf:"bytes,1,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	State       Execution_State        `protobuf:"varint,2,opt,name=state,proto3,enum=executor.v1.Execution_State" json:"state,omitempty"`
+	JobName     string                 `protobuf:"bytes,3,opt,name=job_name,json=jobName,proto3" json:"job_name,omitempty"`
+	PodName     string                 `protobuf:"bytes,4,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	// Set once the execution reaches a terminal state (or was rejected before a Job was created).
+	Result        *ExecuteResponse       `protobuf:"bytes,5,opt,name=result,proto3" json:"result,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	FinishedAt    *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Execution) Reset() {
+	*x = Execution{}
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Execution) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Execution) ProtoMessage() {}
+
+func (x *Execution) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Execution.ProtoReflect.Descriptor instead.
+func (*Execution) Descriptor() ([]byte, []int) {
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Execution) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *Execution) GetState() Execution_State {
+	if x != nil {
+		return x.State
+	}
+	return Execution_STATE_UNSPECIFIED
+}
+
+func (x *Execution) GetJobName() string {
+	if x != nil {
+		return x.JobName
+	}
+	return ""
+}
+
+func (x *Execution) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+func (x *Execution) GetResult() *ExecuteResponse {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *Execution) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Execution) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+type GetExecutionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExecutionId   string                 `protobuf:"bytes,1,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetExecutionRequest) Reset() {
+	*x = GetExecutionRequest{}
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetExecutionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetExecutionRequest) ProtoMessage() {}
+
+func (x *GetExecutionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetExecutionRequest.ProtoReflect.Descriptor instead.
+func (*GetExecutionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetExecutionRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+type CancelExecutionRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ExecutionId string                 `protobuf:"bytes,1,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	// Grace period given to the script pod; defaults to the configured cancel grace period.
+	GracePeriod   *durationpb.Duration `protobuf:"bytes,2,opt,name=grace_period,json=gracePeriod,proto3" json:"grace_period,omitempty"`
+	Reason        string               `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelExecutionRequest) Reset() {
+	*x = CancelExecutionRequest{}
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelExecutionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelExecutionRequest) ProtoMessage() {}
+
+func (x *CancelExecutionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelExecutionRequest.ProtoReflect.Descriptor instead.
+func (*CancelExecutionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CancelExecutionRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *CancelExecutionRequest) GetGracePeriod() *durationpb.Duration {
+	if x != nil {
+		return x.GracePeriod
+	}
+	return nil
+}
+
+func (x *CancelExecutionRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type GetLogRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	LogRef string                 `protobuf:"bytes,1,opt,name=log_ref,json=logRef,proto3" json:"log_ref,omitempty"`
+	// "stdout" or "stderr"; empty returns both, interleaved as written.
+	Stream        string `protobuf:"bytes,2,opt,name=stream,proto3" json:"stream,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLogRequest) Reset() {
+	*x = GetLogRequest{}
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLogRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLogRequest) ProtoMessage() {}
+
+func (x *GetLogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLogRequest.ProtoReflect.Descriptor instead.
+func (*GetLogRequest) Descriptor() ([]byte, []int) {
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetLogRequest) GetLogRef() string {
+	if x != nil {
+		return x.LogRef
+	}
+	return ""
+}
+
+func (x *GetLogRequest) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+type LogChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "stdout" or "stderr".
+	Stream        string `protobuf:"bytes,1,opt,name=stream,proto3" json:"stream,omitempty"`
+	Data          []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogChunk) Reset() {
+	*x = LogChunk{}
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogChunk) ProtoMessage() {}
+
+func (x *LogChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogChunk.ProtoReflect.Descriptor instead.
+func (*LogChunk) Descriptor() ([]byte, []int) {
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *LogChunk) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *LogChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type GetArtifactRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           string                 `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetArtifactRequest) Reset() {
+	*x = GetArtifactRequest{}
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetArtifactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetArtifactRequest) ProtoMessage() {}
+
+func (x *GetArtifactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetArtifactRequest.ProtoReflect.Descriptor instead.
+func (*GetArtifactRequest) Descriptor() ([]byte, []int) {
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetArtifactRequest) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+type ArtifactChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArtifactChunk) Reset() {
+	*x = ArtifactChunk{}
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArtifactChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArtifactChunk) ProtoMessage() {}
+
+func (x *ArtifactChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArtifactChunk.ProtoReflect.Descriptor instead.
+func (*ArtifactChunk) Descriptor() ([]byte, []int) {
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ArtifactChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
 type DescribeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	StepTypes     []string               `protobuf:"bytes,1,rep,name=step_types,json=stepTypes,proto3" json:"step_types,omitempty"`
@@ -635,7 +1088,7 @@ type DescribeRequest struct {
 
 func (x *DescribeRequest) Reset() {
 	*x = DescribeRequest{}
-	mi := &file_proto_executor_v1_executor_proto_msgTypes[6]
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -647,7 +1100,7 @@ func (x *DescribeRequest) String() string {
 func (*DescribeRequest) ProtoMessage() {}
 
 func (x *DescribeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_executor_v1_executor_proto_msgTypes[6]
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -660,7 +1113,7 @@ func (x *DescribeRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DescribeRequest.ProtoReflect.Descriptor instead.
 func (*DescribeRequest) Descriptor() ([]byte, []int) {
-	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{6}
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *DescribeRequest) GetStepTypes() []string {
@@ -682,7 +1135,7 @@ type DescribeResponse struct {
 
 func (x *DescribeResponse) Reset() {
 	*x = DescribeResponse{}
-	mi := &file_proto_executor_v1_executor_proto_msgTypes[7]
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -694,7 +1147,7 @@ func (x *DescribeResponse) String() string {
 func (*DescribeResponse) ProtoMessage() {}
 
 func (x *DescribeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_executor_v1_executor_proto_msgTypes[7]
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -707,7 +1160,7 @@ func (x *DescribeResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DescribeResponse.ProtoReflect.Descriptor instead.
 func (*DescribeResponse) Descriptor() ([]byte, []int) {
-	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{7}
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *DescribeResponse) GetName() string {
@@ -752,7 +1205,7 @@ type StepTypeCapability struct {
 
 func (x *StepTypeCapability) Reset() {
 	*x = StepTypeCapability{}
-	mi := &file_proto_executor_v1_executor_proto_msgTypes[8]
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -764,7 +1217,7 @@ func (x *StepTypeCapability) String() string {
 func (*StepTypeCapability) ProtoMessage() {}
 
 func (x *StepTypeCapability) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_executor_v1_executor_proto_msgTypes[8]
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -777,7 +1230,7 @@ func (x *StepTypeCapability) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StepTypeCapability.ProtoReflect.Descriptor instead.
 func (*StepTypeCapability) Descriptor() ([]byte, []int) {
-	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{8}
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *StepTypeCapability) GetType() string {
@@ -831,7 +1284,7 @@ type HealthRequest struct {
 
 func (x *HealthRequest) Reset() {
 	*x = HealthRequest{}
-	mi := &file_proto_executor_v1_executor_proto_msgTypes[9]
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -843,7 +1296,7 @@ func (x *HealthRequest) String() string {
 func (*HealthRequest) ProtoMessage() {}
 
 func (x *HealthRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_executor_v1_executor_proto_msgTypes[9]
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -856,7 +1309,7 @@ func (x *HealthRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
 func (*HealthRequest) Descriptor() ([]byte, []int) {
-	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{9}
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *HealthRequest) GetChecks() []string {
@@ -877,7 +1330,7 @@ type HealthResponse struct {
 
 func (x *HealthResponse) Reset() {
 	*x = HealthResponse{}
-	mi := &file_proto_executor_v1_executor_proto_msgTypes[10]
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -889,7 +1342,7 @@ func (x *HealthResponse) String() string {
 func (*HealthResponse) ProtoMessage() {}
 
 func (x *HealthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_executor_v1_executor_proto_msgTypes[10]
+	mi := &file_proto_executor_v1_executor_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -902,7 +1355,7 @@ func (x *HealthResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
 func (*HealthResponse) Descriptor() ([]byte, []int) {
-	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{10}
+	return file_proto_executor_v1_executor_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *HealthResponse) GetStatus() HealthResponse_Status {
@@ -988,7 +1441,41 @@ const file_proto_executor_v1_executor_proto_rawDesc = "" +
 	"\rSTAGE_RUNNING\x10\x02\x12\x14\n" +
 	"\x10STAGE_COMPLETING\x10\x03\x12\x0e\n" +
 	"\n" +
-	"STAGE_DONE\x10\x04\"0\n" +
+	"STAGE_DONE\x10\x04\"\xdc\x03\n" +
+	"\tExecution\x12!\n" +
+	"\fexecution_id\x18\x01 \x01(\tR\vexecutionId\x122\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x1c.executor.v1.Execution.StateR\x05state\x12\x19\n" +
+	"\bjob_name\x18\x03 \x01(\tR\ajobName\x12\x19\n" +
+	"\bpod_name\x18\x04 \x01(\tR\apodName\x124\n" +
+	"\x06result\x18\x05 \x01(\v2\x1c.executor.v1.ExecuteResponseR\x06result\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12;\n" +
+	"\vfinished_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\"\x93\x01\n" +
+	"\x05State\x12\x15\n" +
+	"\x11STATE_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rSTATE_PENDING\x10\x01\x12\x11\n" +
+	"\rSTATE_RUNNING\x10\x02\x12\x13\n" +
+	"\x0fSTATE_SUCCEEDED\x10\x03\x12\x10\n" +
+	"\fSTATE_FAILED\x10\x04\x12\x11\n" +
+	"\rSTATE_TIMEOUT\x10\x05\x12\x13\n" +
+	"\x0fSTATE_CANCELLED\x10\x06\"8\n" +
+	"\x13GetExecutionRequest\x12!\n" +
+	"\fexecution_id\x18\x01 \x01(\tR\vexecutionId\"\x91\x01\n" +
+	"\x16CancelExecutionRequest\x12!\n" +
+	"\fexecution_id\x18\x01 \x01(\tR\vexecutionId\x12<\n" +
+	"\fgrace_period\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\vgracePeriod\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"@\n" +
+	"\rGetLogRequest\x12\x17\n" +
+	"\alog_ref\x18\x01 \x01(\tR\x06logRef\x12\x16\n" +
+	"\x06stream\x18\x02 \x01(\tR\x06stream\"6\n" +
+	"\bLogChunk\x12\x16\n" +
+	"\x06stream\x18\x01 \x01(\tR\x06stream\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"&\n" +
+	"\x12GetArtifactRequest\x12\x10\n" +
+	"\x03ref\x18\x01 \x01(\tR\x03ref\"#\n" +
+	"\rArtifactChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"0\n" +
 	"\x0fDescribeRequest\x12\x1d\n" +
 	"\n" +
 	"step_types\x18\x01 \x03(\tR\tstepTypes\"\x86\x02\n" +
@@ -1021,12 +1508,17 @@ const file_proto_executor_v1_executor_proto_rawDesc = "" +
 	"\x0eSTATUS_UNKNOWN\x10\x00\x12\x12\n" +
 	"\x0eSTATUS_SERVING\x10\x01\x12\x16\n" +
 	"\x12STATUS_NOT_SERVING\x10\x02\x12\x13\n" +
-	"\x0fSTATUS_DEGRADED\x10\x032\xaa\x02\n" +
+	"\x0fSTATUS_DEGRADED\x10\x032\x96\x05\n" +
 	"\bExecutor\x12D\n" +
 	"\aExecute\x12\x1b.executor.v1.ExecuteRequest\x1a\x1c.executor.v1.ExecuteResponse\x12L\n" +
 	"\rExecuteStream\x12\x1b.executor.v1.ExecuteRequest\x1a\x1c.executor.v1.ExecuteProgress0\x01\x12G\n" +
 	"\bDescribe\x12\x1c.executor.v1.DescribeRequest\x1a\x1d.executor.v1.DescribeResponse\x12A\n" +
-	"\x06Health\x12\x1a.executor.v1.HealthRequest\x1a\x1b.executor.v1.HealthResponseB\xbd\x01\n" +
+	"\x06Health\x12\x1a.executor.v1.HealthRequest\x1a\x1b.executor.v1.HealthResponse\x12C\n" +
+	"\fExecuteAsync\x12\x1b.executor.v1.ExecuteRequest\x1a\x16.executor.v1.Execution\x12H\n" +
+	"\fGetExecution\x12 .executor.v1.GetExecutionRequest\x1a\x16.executor.v1.Execution\x12N\n" +
+	"\x0fCancelExecution\x12#.executor.v1.CancelExecutionRequest\x1a\x16.executor.v1.Execution\x12=\n" +
+	"\x06GetLog\x12\x1a.executor.v1.GetLogRequest\x1a\x15.executor.v1.LogChunk0\x01\x12L\n" +
+	"\vGetArtifact\x12\x1f.executor.v1.GetArtifactRequest\x1a\x1a.executor.v1.ArtifactChunk0\x01B\xbd\x01\n" +
 	"\x0fcom.executor.v1B\rExecutorProtoP\x01ZNgithub.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1;executorv1\xa2\x02\x03EXX\xaa\x02\vExecutor.V1\xca\x02\vExecutor\\V1\xe2\x02\x17Executor\\V1\\GPBMetadata\xea\x02\fExecutor::V1b\x06proto3"
 
 var (
@@ -1041,66 +1533,89 @@ func file_proto_executor_v1_executor_proto_rawDescGZIP() []byte {
 	return file_proto_executor_v1_executor_proto_rawDescData
 }
 
-var file_proto_executor_v1_executor_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_proto_executor_v1_executor_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_proto_executor_v1_executor_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_proto_executor_v1_executor_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_proto_executor_v1_executor_proto_goTypes = []any{
-	(ExecuteResponse_Status)(0),   // 0: executor.v1.ExecuteResponse.Status
-	(ExecuteProgress_Stage)(0),    // 1: executor.v1.ExecuteProgress.Stage
-	(HealthResponse_Status)(0),    // 2: executor.v1.HealthResponse.Status
-	(*ExecuteRequest)(nil),        // 3: executor.v1.ExecuteRequest
-	(*ExecutionContext)(nil),      // 4: executor.v1.ExecutionContext
-	(*ExecuteResponse)(nil),       // 5: executor.v1.ExecuteResponse
-	(*ErrorDetails)(nil),          // 6: executor.v1.ErrorDetails
-	(*FieldViolation)(nil),        // 7: executor.v1.FieldViolation
-	(*ExecuteProgress)(nil),       // 8: executor.v1.ExecuteProgress
-	(*DescribeRequest)(nil),       // 9: executor.v1.DescribeRequest
-	(*DescribeResponse)(nil),      // 10: executor.v1.DescribeResponse
-	(*StepTypeCapability)(nil),    // 11: executor.v1.StepTypeCapability
-	(*HealthRequest)(nil),         // 12: executor.v1.HealthRequest
-	(*HealthResponse)(nil),        // 13: executor.v1.HealthResponse
-	nil,                           // 14: executor.v1.ExecutionContext.LabelsEntry
-	nil,                           // 15: executor.v1.ErrorDetails.MetadataEntry
-	nil,                           // 16: executor.v1.DescribeResponse.MetadataEntry
-	nil,                           // 17: executor.v1.HealthResponse.DetailsEntry
-	(*structpb.Struct)(nil),       // 18: google.protobuf.Struct
-	(*durationpb.Duration)(nil),   // 19: google.protobuf.Duration
-	(*timestamppb.Timestamp)(nil), // 20: google.protobuf.Timestamp
+	(ExecuteResponse_Status)(0),    // 0: executor.v1.ExecuteResponse.Status
+	(ExecuteProgress_Stage)(0),     // 1: executor.v1.ExecuteProgress.Stage
+	(Execution_State)(0),           // 2: executor.v1.Execution.State
+	(HealthResponse_Status)(0),     // 3: executor.v1.HealthResponse.Status
+	(*ExecuteRequest)(nil),         // 4: executor.v1.ExecuteRequest
+	(*ExecutionContext)(nil),       // 5: executor.v1.ExecutionContext
+	(*ExecuteResponse)(nil),        // 6: executor.v1.ExecuteResponse
+	(*ErrorDetails)(nil),           // 7: executor.v1.ErrorDetails
+	(*FieldViolation)(nil),         // 8: executor.v1.FieldViolation
+	(*ExecuteProgress)(nil),        // 9: executor.v1.ExecuteProgress
+	(*Execution)(nil),              // 10: executor.v1.Execution
+	(*GetExecutionRequest)(nil),    // 11: executor.v1.GetExecutionRequest
+	(*CancelExecutionRequest)(nil), // 12: executor.v1.CancelExecutionRequest
+	(*GetLogRequest)(nil),          // 13: executor.v1.GetLogRequest
+	(*LogChunk)(nil),               // 14: executor.v1.LogChunk
+	(*GetArtifactRequest)(nil),     // 15: executor.v1.GetArtifactRequest
+	(*ArtifactChunk)(nil),          // 16: executor.v1.ArtifactChunk
+	(*DescribeRequest)(nil),        // 17: executor.v1.DescribeRequest
+	(*DescribeResponse)(nil),       // 18: executor.v1.DescribeResponse
+	(*StepTypeCapability)(nil),     // 19: executor.v1.StepTypeCapability
+	(*HealthRequest)(nil),          // 20: executor.v1.HealthRequest
+	(*HealthResponse)(nil),         // 21: executor.v1.HealthResponse
+	nil,                            // 22: executor.v1.ExecutionContext.LabelsEntry
+	nil,                            // 23: executor.v1.ErrorDetails.MetadataEntry
+	nil,                            // 24: executor.v1.DescribeResponse.MetadataEntry
+	nil,                            // 25: executor.v1.HealthResponse.DetailsEntry
+	(*structpb.Struct)(nil),        // 26: google.protobuf.Struct
+	(*durationpb.Duration)(nil),    // 27: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil),  // 28: google.protobuf.Timestamp
 }
 var file_proto_executor_v1_executor_proto_depIdxs = []int32{
-	18, // 0: executor.v1.ExecuteRequest.parameters:type_name -> google.protobuf.Struct
-	4,  // 1: executor.v1.ExecuteRequest.context:type_name -> executor.v1.ExecutionContext
-	19, // 2: executor.v1.ExecuteRequest.timeout:type_name -> google.protobuf.Duration
-	14, // 3: executor.v1.ExecutionContext.labels:type_name -> executor.v1.ExecutionContext.LabelsEntry
-	20, // 4: executor.v1.ExecutionContext.started_at:type_name -> google.protobuf.Timestamp
+	26, // 0: executor.v1.ExecuteRequest.parameters:type_name -> google.protobuf.Struct
+	5,  // 1: executor.v1.ExecuteRequest.context:type_name -> executor.v1.ExecutionContext
+	27, // 2: executor.v1.ExecuteRequest.timeout:type_name -> google.protobuf.Duration
+	22, // 3: executor.v1.ExecutionContext.labels:type_name -> executor.v1.ExecutionContext.LabelsEntry
+	28, // 4: executor.v1.ExecutionContext.started_at:type_name -> google.protobuf.Timestamp
 	0,  // 5: executor.v1.ExecuteResponse.status:type_name -> executor.v1.ExecuteResponse.Status
-	18, // 6: executor.v1.ExecuteResponse.output:type_name -> google.protobuf.Struct
-	19, // 7: executor.v1.ExecuteResponse.duration:type_name -> google.protobuf.Duration
-	6,  // 8: executor.v1.ExecuteResponse.error_details:type_name -> executor.v1.ErrorDetails
-	7,  // 9: executor.v1.ErrorDetails.field_violations:type_name -> executor.v1.FieldViolation
-	15, // 10: executor.v1.ErrorDetails.metadata:type_name -> executor.v1.ErrorDetails.MetadataEntry
+	26, // 6: executor.v1.ExecuteResponse.output:type_name -> google.protobuf.Struct
+	27, // 7: executor.v1.ExecuteResponse.duration:type_name -> google.protobuf.Duration
+	7,  // 8: executor.v1.ExecuteResponse.error_details:type_name -> executor.v1.ErrorDetails
+	8,  // 9: executor.v1.ErrorDetails.field_violations:type_name -> executor.v1.FieldViolation
+	23, // 10: executor.v1.ErrorDetails.metadata:type_name -> executor.v1.ErrorDetails.MetadataEntry
 	1,  // 11: executor.v1.ExecuteProgress.stage:type_name -> executor.v1.ExecuteProgress.Stage
-	18, // 12: executor.v1.ExecuteProgress.metadata:type_name -> google.protobuf.Struct
-	20, // 13: executor.v1.ExecuteProgress.timestamp:type_name -> google.protobuf.Timestamp
-	5,  // 14: executor.v1.ExecuteProgress.result:type_name -> executor.v1.ExecuteResponse
-	11, // 15: executor.v1.DescribeResponse.step_types:type_name -> executor.v1.StepTypeCapability
-	16, // 16: executor.v1.DescribeResponse.metadata:type_name -> executor.v1.DescribeResponse.MetadataEntry
-	19, // 17: executor.v1.StepTypeCapability.typical_duration:type_name -> google.protobuf.Duration
-	2,  // 18: executor.v1.HealthResponse.status:type_name -> executor.v1.HealthResponse.Status
-	17, // 19: executor.v1.HealthResponse.details:type_name -> executor.v1.HealthResponse.DetailsEntry
-	20, // 20: executor.v1.HealthResponse.timestamp:type_name -> google.protobuf.Timestamp
-	3,  // 21: executor.v1.Executor.Execute:input_type -> executor.v1.ExecuteRequest
-	3,  // 22: executor.v1.Executor.ExecuteStream:input_type -> executor.v1.ExecuteRequest
-	9,  // 23: executor.v1.Executor.Describe:input_type -> executor.v1.DescribeRequest
-	12, // 24: executor.v1.Executor.Health:input_type -> executor.v1.HealthRequest
-	5,  // 25: executor.v1.Executor.Execute:output_type -> executor.v1.ExecuteResponse
-	8,  // 26: executor.v1.Executor.ExecuteStream:output_type -> executor.v1.ExecuteProgress
-	10, // 27: executor.v1.Executor.Describe:output_type -> executor.v1.DescribeResponse
-	13, // 28: executor.v1.Executor.Health:output_type -> executor.v1.HealthResponse
-	25, // [25:29] is the sub-list for method output_type
-	21, // [21:25] is the sub-list for method input_type
-	21, // [21:21] is the sub-list for extension type_name
-	21, // [21:21] is the sub-list for extension extendee
-	0,  // [0:21] is the sub-list for field type_name
+	26, // 12: executor.v1.ExecuteProgress.metadata:type_name -> google.protobuf.Struct
+	28, // 13: executor.v1.ExecuteProgress.timestamp:type_name -> google.protobuf.Timestamp
+	6,  // 14: executor.v1.ExecuteProgress.result:type_name -> executor.v1.ExecuteResponse
+	2,  // 15: executor.v1.Execution.state:type_name -> executor.v1.Execution.State
+	6,  // 16: executor.v1.Execution.result:type_name -> executor.v1.ExecuteResponse
+	28, // 17: executor.v1.Execution.created_at:type_name -> google.protobuf.Timestamp
+	28, // 18: executor.v1.Execution.finished_at:type_name -> google.protobuf.Timestamp
+	27, // 19: executor.v1.CancelExecutionRequest.grace_period:type_name -> google.protobuf.Duration
+	19, // 20: executor.v1.DescribeResponse.step_types:type_name -> executor.v1.StepTypeCapability
+	24, // 21: executor.v1.DescribeResponse.metadata:type_name -> executor.v1.DescribeResponse.MetadataEntry
+	27, // 22: executor.v1.StepTypeCapability.typical_duration:type_name -> google.protobuf.Duration
+	3,  // 23: executor.v1.HealthResponse.status:type_name -> executor.v1.HealthResponse.Status
+	25, // 24: executor.v1.HealthResponse.details:type_name -> executor.v1.HealthResponse.DetailsEntry
+	28, // 25: executor.v1.HealthResponse.timestamp:type_name -> google.protobuf.Timestamp
+	4,  // 26: executor.v1.Executor.Execute:input_type -> executor.v1.ExecuteRequest
+	4,  // 27: executor.v1.Executor.ExecuteStream:input_type -> executor.v1.ExecuteRequest
+	17, // 28: executor.v1.Executor.Describe:input_type -> executor.v1.DescribeRequest
+	20, // 29: executor.v1.Executor.Health:input_type -> executor.v1.HealthRequest
+	4,  // 30: executor.v1.Executor.ExecuteAsync:input_type -> executor.v1.ExecuteRequest
+	11, // 31: executor.v1.Executor.GetExecution:input_type -> executor.v1.GetExecutionRequest
+	12, // 32: executor.v1.Executor.CancelExecution:input_type -> executor.v1.CancelExecutionRequest
+	13, // 33: executor.v1.Executor.GetLog:input_type -> executor.v1.GetLogRequest
+	15, // 34: executor.v1.Executor.GetArtifact:input_type -> executor.v1.GetArtifactRequest
+	6,  // 35: executor.v1.Executor.Execute:output_type -> executor.v1.ExecuteResponse
+	9,  // 36: executor.v1.Executor.ExecuteStream:output_type -> executor.v1.ExecuteProgress
+	18, // 37: executor.v1.Executor.Describe:output_type -> executor.v1.DescribeResponse
+	21, // 38: executor.v1.Executor.Health:output_type -> executor.v1.HealthResponse
+	10, // 39: executor.v1.Executor.ExecuteAsync:output_type -> executor.v1.Execution
+	10, // 40: executor.v1.Executor.GetExecution:output_type -> executor.v1.Execution
+	10, // 41: executor.v1.Executor.CancelExecution:output_type -> executor.v1.Execution
+	14, // 42: executor.v1.Executor.GetLog:output_type -> executor.v1.LogChunk
+	16, // 43: executor.v1.Executor.GetArtifact:output_type -> executor.v1.ArtifactChunk
+	35, // [35:44] is the sub-list for method output_type
+	26, // [26:35] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_proto_executor_v1_executor_proto_init() }
@@ -1113,8 +1628,8 @@ func file_proto_executor_v1_executor_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_executor_v1_executor_proto_rawDesc), len(file_proto_executor_v1_executor_proto_rawDesc)),
-			NumEnums:      3,
-			NumMessages:   15,
+			NumEnums:      4,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,10 +19,15 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Executor_Execute_FullMethodName       = "/executor.v1.Executor/Execute"
-	Executor_ExecuteStream_FullMethodName = "/executor.v1.Executor/ExecuteStream"
-	Executor_Describe_FullMethodName      = "/executor.v1.Executor/Describe"
-	Executor_Health_FullMethodName        = "/executor.v1.Executor/Health"
+	Executor_Execute_FullMethodName         = "/executor.v1.Executor/Execute"
+	Executor_ExecuteStream_FullMethodName   = "/executor.v1.Executor/ExecuteStream"
+	Executor_Describe_FullMethodName        = "/executor.v1.Executor/Describe"
+	Executor_Health_FullMethodName          = "/executor.v1.Executor/Health"
+	Executor_ExecuteAsync_FullMethodName    = "/executor.v1.Executor/ExecuteAsync"
+	Executor_GetExecution_FullMethodName    = "/executor.v1.Executor/GetExecution"
+	Executor_CancelExecution_FullMethodName = "/executor.v1.Executor/CancelExecution"
+	Executor_GetLog_FullMethodName          = "/executor.v1.Executor/GetLog"
+	Executor_GetArtifact_FullMethodName     = "/executor.v1.Executor/GetArtifact"
 )
 
 // ExecutorClient is the client API for Executor service.
@@ -42,6 +47,19 @@ type ExecutorClient interface {
 	Describe(ctx context.Context, in *DescribeRequest, opts ...grpc.CallOption) (*DescribeResponse, error)
 	// Health returns the executor's health status.
 	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	// ExecuteAsync submits a step and returns a handle without waiting for it to finish.
+	// Poll GetExecution with the returned execution_id to follow it.
+	ExecuteAsync(ctx context.Context, in *ExecuteRequest, opts ...grpc.CallOption) (*Execution, error)
+	// GetExecution returns the current state of a submitted execution.
+	GetExecution(ctx context.Context, in *GetExecutionRequest, opts ...grpc.CallOption) (*Execution, error)
+	// CancelExecution stops a running execution by deleting its Job.
+	CancelExecution(ctx context.Context, in *CancelExecutionRequest, opts ...grpc.CallOption) (*Execution, error)
+	// GetLog streams the full log of an execution whose output was truncated,
+	// referenced by the log_ref field of its output.
+	GetLog(ctx context.Context, in *GetLogRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogChunk], error)
+	// GetArtifact streams a file collected by the artifacts parameter,
+	// referenced by the ref field of its entry in the output.
+	GetArtifact(ctx context.Context, in *GetArtifactRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ArtifactChunk], error)
 }
 
 type executorClient struct {
@@ -101,6 +119,74 @@ func (c *executorClient) Health(ctx context.Context, in *HealthRequest, opts ...
 	return out, nil
 }
 
+func (c *executorClient) ExecuteAsync(ctx context.Context, in *ExecuteRequest, opts ...grpc.CallOption) (*Execution, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Execution)
+	err := c.cc.Invoke(ctx, Executor_ExecuteAsync_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorClient) GetExecution(ctx context.Context, in *GetExecutionRequest, opts ...grpc.CallOption) (*Execution, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Execution)
+	err := c.cc.Invoke(ctx, Executor_GetExecution_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorClient) CancelExecution(ctx context.Context, in *CancelExecutionRequest, opts ...grpc.CallOption) (*Execution, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Execution)
+	err := c.cc.Invoke(ctx, Executor_CancelExecution_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorClient) GetLog(ctx context.Context, in *GetLogRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Executor_ServiceDesc.Streams[1], Executor_GetLog_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetLogRequest, LogChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Executor_GetLogClient = grpc.ServerStreamingClient[LogChunk]
+
+func (c *executorClient) GetArtifact(ctx context.Context, in *GetArtifactRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ArtifactChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Executor_ServiceDesc.Streams[2], Executor_GetArtifact_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetArtifactRequest, ArtifactChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Executor_GetArtifactClient = grpc.ServerStreamingClient[ArtifactChunk]
+
 // ExecutorServer is the server API for Executor service.
 // All implementations must embed UnimplementedExecutorServer
 // for forward compatibility.
@@ -118,6 +204,19 @@ type ExecutorServer interface {
 	Describe(context.Context, *DescribeRequest) (*DescribeResponse, error)
 	// Health returns the executor's health status.
 	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	// ExecuteAsync submits a step and returns a handle without waiting for it to finish.
+	// Poll GetExecution with the returned execution_id to follow it.
+	ExecuteAsync(context.Context, *ExecuteRequest) (*Execution, error)
+	// GetExecution returns the current state of a submitted execution.
+	GetExecution(context.Context, *GetExecutionRequest) (*Execution, error)
+	// CancelExecution stops a running execution by deleting its Job.
+	CancelExecution(context.Context, *CancelExecutionRequest) (*Execution, error)
+	// GetLog streams the full log of an execution whose output was truncated,
+	// referenced by the log_ref field of its output.
+	GetLog(*GetLogRequest, grpc.ServerStreamingServer[LogChunk]) error
+	// GetArtifact streams a file collected by the artifacts parameter,
+	// referenced by the ref field of its entry in the output.
+	GetArtifact(*GetArtifactRequest, grpc.ServerStreamingServer[ArtifactChunk]) error
 	mustEmbedUnimplementedExecutorServer()
 }
 
@@ -140,6 +239,21 @@ func (UnimplementedExecutorServer) Describe(context.Context, *DescribeRequest) (
 func (UnimplementedExecutorServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Health not implemented")
 }
+func (UnimplementedExecutorServer) ExecuteAsync(context.Context, *ExecuteRequest) (*Execution, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExecuteAsync not implemented")
+}
+func (UnimplementedExecutorServer) GetExecution(context.Context, *GetExecutionRequest) (*Execution, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetExecution not implemented")
+}
+func (UnimplementedExecutorServer) CancelExecution(context.Context, *CancelExecutionRequest) (*Execution, error) {
+	return nil, status.Error(codes.Unimplemented, "method CancelExecution not implemented")
+}
+func (UnimplementedExecutorServer) GetLog(*GetLogRequest, grpc.ServerStreamingServer[LogChunk]) error {
+	return status.Error(codes.Unimplemented, "method GetLog not implemented")
+}
+func (UnimplementedExecutorServer) GetArtifact(*GetArtifactRequest, grpc.ServerStreamingServer[ArtifactChunk]) error {
+	return status.Error(codes.Unimplemented, "method GetArtifact not implemented")
+}
 func (UnimplementedExecutorServer) mustEmbedUnimplementedExecutorServer() {}
 func (UnimplementedExecutorServer) testEmbeddedByValue()                  {}
 
@@ -226,6 +340,82 @@ func _Executor_Health_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Executor_ExecuteAsync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecuteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorServer).ExecuteAsync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Executor_ExecuteAsync_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorServer).ExecuteAsync(ctx, req.(*ExecuteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Executor_GetExecution_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetExecutionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorServer).GetExecution(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Executor_GetExecution_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorServer).GetExecution(ctx, req.(*GetExecutionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Executor_CancelExecution_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelExecutionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorServer).CancelExecution(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Executor_CancelExecution_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorServer).CancelExecution(ctx, req.(*CancelExecutionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Executor_GetLog_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetLogRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExecutorServer).GetLog(m, &grpc.GenericServerStream[GetLogRequest, LogChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Executor_GetLogServer = grpc.ServerStreamingServer[LogChunk]
+
+func _Executor_GetArtifact_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetArtifactRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExecutorServer).GetArtifact(m, &grpc.GenericServerStream[GetArtifactRequest, ArtifactChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Executor_GetArtifactServer = grpc.ServerStreamingServer[ArtifactChunk]
+
 // Executor_ServiceDesc is the grpc.ServiceDesc for Executor service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -245,6 +435,18 @@ var Executor_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Health",
 			Handler:    _Executor_Health_Handler,
 		},
+		{
+			MethodName: "ExecuteAsync",
+			Handler:    _Executor_ExecuteAsync_Handler,
+		},
+		{
+			MethodName: "GetExecution",
+			Handler:    _Executor_GetExecution_Handler,
+		},
+		{
+			MethodName: "CancelExecution",
+			Handler:    _Executor_CancelExecution_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -252,6 +454,16 @@ var Executor_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Executor_ExecuteStream_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetLog",
+			Handler:       _Executor_GetLog_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetArtifact",
+			Handler:       _Executor_GetArtifact_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "proto/executor/v1/executor.proto",
 }
@@ -0,0 +1,182 @@
+// Package artifact stores files collected from an execution's workspace.
+package artifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rakeshavasarala/script-executor/internal/config"
+)
+
+// ErrNotFound is returned when no artifact exists for a reference.
+var ErrNotFound = errors.New("artifact not found")
+
+// Store keeps artifacts. Keys and references are slash-separated relative
+// paths such as "<execution_id>/reports/summary.txt".
+type Store interface {
+	// Put stores data under key and returns its reference.
+	Put(ctx context.Context, key string, data []byte) (string, error)
+	// Open returns the artifact with the given reference.
+	Open(ctx context.Context, ref string) (io.ReadCloser, error)
+}
+
+// Pruner is implemented by stores that delete old artifacts themselves. Other
+// stores rely on the backend's expiry, such as S3 lifecycle rules.
+type Pruner interface {
+	// Prune deletes the artifacts of executions whose last artifact was stored
+	// before the given time, then those of the oldest executions until the
+	// rest take at most maxBytes (no limit if zero). It returns how many
+	// executions' artifacts it deleted.
+	Prune(ctx context.Context, before time.Time, maxBytes int64) (int, error)
+}
+
+// New returns the store for the configured backend. An empty type or "none"
+// disables artifact storage and returns nil.
+func New(cfg config.ArtifactStoreConfig) (Store, error) {
+	switch cfg.Type {
+	case "", "none":
+		return nil, nil
+	case "filesystem":
+		return NewFilesystemStore(cfg.Path)
+	case "s3":
+		return NewS3Store(S3Options{
+			Endpoint:        cfg.Endpoint,
+			Bucket:          cfg.Bucket,
+			Region:          cfg.Region,
+			Prefix:          cfg.Prefix,
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		})
+	}
+	return nil, fmt.Errorf("unknown artifact store type %q", cfg.Type)
+}
+
+// ValidKey reports whether key is a clean relative path that stays inside the
+// store.
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) || path.Clean(key) != key {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return false
+		}
+	}
+	return true
+}
+
+// FilesystemStore implements Store with one file per artifact under a
+// directory. References are the keys.
+type FilesystemStore struct {
+	dir string
+}
+
+// NewFilesystemStore creates a filesystem artifact store, creating dir if
+// needed.
+func NewFilesystemStore(dir string) (*FilesystemStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create artifact directory: %w", err)
+	}
+	return &FilesystemStore{dir: dir}, nil
+}
+
+// Put implements Store.
+func (s *FilesystemStore) Put(ctx context.Context, key string, data []byte) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid artifact key %q", key)
+	}
+	p := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return "", fmt.Errorf("create artifact directory: %w", err)
+	}
+	if err := os.WriteFile(p, data, 0o640); err != nil {
+		return "", fmt.Errorf("write artifact: %w", err)
+	}
+	return key, nil
+}
+
+// Open implements Store.
+func (s *FilesystemStore) Open(ctx context.Context, ref string) (io.ReadCloser, error) {
+	if !ValidKey(ref) {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(ref)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open artifact: %w", err)
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		f.Close()
+		return nil, ErrNotFound
+	}
+	return f, nil
+}
+
+// Prune implements Pruner. Artifacts are grouped by the first segment of their
+// key, the execution ID, and dated by their newest file.
+func (s *FilesystemStore) Prune(ctx context.Context, before time.Time, maxBytes int64) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("list artifacts: %w", err)
+	}
+	type execution struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+	var executions []execution
+	var total int64
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		ex := execution{name: e.Name()}
+		err := filepath.WalkDir(filepath.Join(s.dir, e.Name()), func(p string, d os.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			ex.size += info.Size()
+			if info.ModTime().After(ex.modTime) {
+				ex.modTime = info.ModTime()
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("list artifacts: %w", err)
+		}
+		executions = append(executions, ex)
+		total += ex.size
+	}
+	sort.Slice(executions, func(i, j int) bool { return executions[i].modTime.Before(executions[j].modTime) })
+
+	deleted := 0
+	for _, ex := range executions {
+		if !ex.modTime.Before(before) && (maxBytes <= 0 || total <= maxBytes) {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		if err := os.RemoveAll(filepath.Join(s.dir, ex.name)); err != nil {
+			return deleted, fmt.Errorf("delete artifacts: %w", err)
+		}
+		total -= ex.size
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,88 @@
+package artifact
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"exec-1/report.txt", true},
+		{"exec-1/reports/a b.html", true},
+		{"", false},
+		{"/exec-1/report.txt", false},
+		{"exec-1/../other/report.txt", false},
+		{"../report.txt", false},
+		{"exec-1/./report.txt", false},
+		{"exec-1//report.txt", false},
+		{`exec-1\report.txt`, false},
+		{"exec-1/", false},
+	}
+	for _, tt := range tests {
+		if got := ValidKey(tt.key); got != tt.want {
+			t.Errorf("ValidKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestFilesystemPrune(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		maxBytes int64
+		want     []string
+	}{
+		{name: "retention only", maxBytes: 0, want: []string{"mid", "new"}},
+		{name: "size limit drops oldest executions", maxBytes: 250, want: []string{"new"}},
+		{name: "size limit already met", maxBytes: 1000, want: []string{"mid", "new"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewFilesystemStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			put := func(key string, age time.Duration) {
+				if _, err := s.Put(ctx, key, []byte(strings.Repeat("x", 100))); err != nil {
+					t.Fatal(err)
+				}
+				mtime := time.Now().Add(-age)
+				if err := os.Chtimes(filepath.Join(s.dir, filepath.FromSlash(key)), mtime, mtime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			put("old/a.txt", 30*24*time.Hour)
+			put("old/b/c.txt", 20*24*time.Hour)
+			// An execution is dated by its newest artifact
+			put("mid/a.txt", 30*24*time.Hour)
+			put("mid/b.txt", time.Hour)
+			put("new/a.txt", time.Minute)
+
+			n, err := s.Prune(ctx, time.Now().Add(-7*24*time.Hour), tt.maxBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 3-len(tt.want) {
+				t.Errorf("Prune deleted %d executions, want %d", n, 3-len(tt.want))
+			}
+			for _, exec := range []string{"old", "mid", "new"} {
+				_, err := os.Stat(filepath.Join(s.dir, exec))
+				kept := err == nil
+				wantKept := false
+				for _, w := range tt.want {
+					wantKept = wantKept || w == exec
+				}
+				if kept != wantKept {
+					t.Errorf("%s kept = %v, want %v", exec, kept, wantKept)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package audit
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"sync"
@@ -26,8 +28,38 @@ func NewLogger(path string, cfg config.AuditConfig) (*Logger, error) {
 	return &Logger{file: file, config: cfg}, nil
 }
 
+// Execution describes a finished script execution.
+type Execution struct {
+	ExecutionID string
+	User        string
+	RunbookID   string
+	ScriptHash  string
+	Source      *script.Source
+	Succeeded   bool
+	Duration    time.Duration
+	ExitCode    int
+	Stdin       string
+	Files       []File
+	Inputs      map[string]interface{}
+	// CachedFrom is the execution whose cached result was returned instead
+	// of running the script
+	CachedFrom  string
+}
+
+// File is an input file materialized into the workspace. Only its path, size
+// and hash are logged, and only the size of a sensitive one unless
+// LogEnvironment is on.
+type File struct {
+	Path      string
+	Content   []byte
+	Sensitive bool
+}
+
+// redacted replaces sensitive values when LogEnvironment is off.
+const redacted = "[REDACTED]"
+
 // LogExecution logs a script execution.
-func (l *Logger) LogExecution(executionID, user, runbookID, scriptHash string, source *script.Source, succeeded bool, duration time.Duration, exitCode int) {
+func (l *Logger) LogExecution(e *Execution) {
 	if l == nil || l.file == nil {
 		return
 	}
@@ -36,20 +68,74 @@ func (l *Logger) LogExecution(executionID, user, runbookID, scriptHash string, s
 
 	evt := map[string]interface{}{
 		"event":         "script_execution",
-		"execution_id":  executionID,
-		"user":          user,
-		"runbook_id":    runbookID,
-		"script_hash":   scriptHash,
-		"succeeded":     succeeded,
-		"duration_sec":  duration.Seconds(),
-		"exit_code":     exitCode,
+		"execution_id":  e.ExecutionID,
+		"user":          e.User,
+		"runbook_id":    e.RunbookID,
+		"script_hash":   e.ScriptHash,
+		"succeeded":     e.Succeeded,
+		"duration_sec":  e.Duration.Seconds(),
+		"exit_code":     e.ExitCode,
 		"timestamp":     time.Now().UTC().Format(time.RFC3339),
 	}
-	if source != nil {
-		evt["script_source"] = string(source.Type)
-		if source.Name != "" {
-			evt["script_ref"] = source.Name + "/" + source.Key
+	if e.Source != nil {
+		evt["script_source"] = string(e.Source.Type)
+		if e.Source.Name != "" {
+			evt["script_ref"] = e.Source.Name + "/" + e.Source.Key
+		}
+	}
+	if e.Stdin != "" {
+		// A hash of a short secret can be brute-forced, so a redacted stdin
+		// is logged by its size only
+		evt["stdin_bytes"] = len(e.Stdin)
+		evt["stdin"] = redacted
+		if l.config.LogEnvironment {
+			h := sha256.Sum256([]byte(e.Stdin))
+			evt["stdin_sha256"] = hex.EncodeToString(h[:])
+			evt["stdin"] = e.Stdin
+		}
+	}
+	if len(e.Files) > 0 {
+		files := make([]map[string]interface{}, 0, len(e.Files))
+		for _, f := range e.Files {
+			file := map[string]interface{}{
+				"path":      f.Path,
+				"bytes":     len(f.Content),
+				"sensitive": f.Sensitive,
+			}
+			if !f.Sensitive || l.config.LogEnvironment {
+				h := sha256.Sum256(f.Content)
+				file["sha256"] = hex.EncodeToString(h[:])
+			}
+			files = append(files, file)
 		}
+		evt["files"] = files
+	}
+	if e.Inputs != nil {
+		evt["inputs"] = e.Inputs
+	}
+	if e.CachedFrom != "" {
+		evt["cached_from"] = e.CachedFrom
+	}
+	data, _ := json.Marshal(evt)
+	l.file.Write(append(data, '\n'))
+}
+
+// LogCancellation logs a cancelled script execution.
+func (l *Logger) LogCancellation(executionID, user, runbookID, scriptHash, reason string) {
+	if l == nil || l.file == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	evt := map[string]interface{}{
+		"event":        "script_cancellation",
+		"execution_id": executionID,
+		"user":         user,
+		"runbook_id":   runbookID,
+		"script_hash":  scriptHash,
+		"reason":       reason,
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
 	}
 	data, _ := json.Marshal(evt)
 	l.file.Write(append(data, '\n'))
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rakeshavasarala/script-executor/internal/config"
+)
+
+func TestLogExecutionRedaction(t *testing.T) {
+	tests := []struct {
+		name           string
+		logEnvironment bool
+		wantStdin      interface{}
+		wantHashes     bool
+	}{
+		{name: "redacted", wantStdin: redacted},
+		{name: "log environment", logEnvironment: true, wantStdin: "hunter2", wantHashes: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			l, err := NewLogger(path, config.AuditConfig{LogEnvironment: tt.logEnvironment})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.LogExecution(&Execution{
+				ExecutionID: "exec-1",
+				Stdin:       "hunter2",
+				Files: []File{
+					{Path: "query.sql", Content: []byte("select 1")},
+					{Path: "token", Content: []byte("s3cret"), Sensitive: true},
+				},
+			})
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var evt map[string]interface{}
+			if err := json.Unmarshal(data, &evt); err != nil {
+				t.Fatal(err)
+			}
+			if evt["stdin"] != tt.wantStdin || evt["stdin_bytes"] != float64(7) {
+				t.Errorf("stdin = %v (%v bytes), want %v", evt["stdin"], evt["stdin_bytes"], tt.wantStdin)
+			}
+			if _, ok := evt["stdin_sha256"]; ok != tt.wantHashes {
+				t.Errorf("stdin_sha256 logged = %v, want %v", ok, tt.wantHashes)
+			}
+			files := evt["files"].([]interface{})
+			if _, ok := files[0].(map[string]interface{})["sha256"]; !ok {
+				t.Error("plain file logged without its hash")
+			}
+			if _, ok := files[1].(map[string]interface{})["sha256"]; ok != tt.wantHashes {
+				t.Errorf("sensitive file hash logged = %v, want %v", ok, tt.wantHashes)
+			}
+		})
+	}
+}
@@ -23,6 +23,9 @@ type ScriptExecutorConfig struct {
 	Approval    ApprovalConfig    `yaml:"approval"`
 	Audit       AuditConfig       `yaml:"audit"`
 	Monitoring  MonitoringConfig  `yaml:"monitoring"`
+	Execution   ExecutionConfig   `yaml:"execution"`
+	Languages   LanguagesConfig   `yaml:"languages"`
+	JobProfiles JobProfilesConfig `yaml:"job_profiles"`
 }
 
 // GRPCConfig holds gRPC server settings.
@@ -38,6 +41,17 @@ type KubernetesConfig struct {
 	JobDefaults    JobDefaultsConfig   `yaml:"job_defaults"`
 	DefaultResources ResourceConfig    `yaml:"default_resources"`
 	MaxResources   ResourceConfig      `yaml:"max_resources"`
+	Tolerations    TolerationPolicyConfig `yaml:"tolerations"`
+}
+
+// TolerationPolicyConfig restricts the taints a script's tolerations may
+// tolerate. A key ending in "*" matches every key with that prefix.
+type TolerationPolicyConfig struct {
+	// AllowedKeys, if set, are the only taint keys that may be tolerated.
+	AllowedKeys []string `yaml:"allowed_keys"`
+	// DeniedKeys may never be tolerated. A toleration without a key, which
+	// tolerates every taint, is denied whenever this is set.
+	DeniedKeys []string `yaml:"denied_keys"`
 }
 
 // JobDefaultsConfig holds Job spec defaults.
@@ -91,6 +105,9 @@ type SecurityConfig struct {
 	BlockedCommands    []string `yaml:"blocked_commands"`
 	MaxScriptSize     int      `yaml:"max_script_size"`
 	MaxScriptLines    int      `yaml:"max_script_lines"`
+	MaxStdinSize      int      `yaml:"max_stdin_size"`
+	MaxOutputsSize    int      `yaml:"max_outputs_size"`
+	MaxFilesSize      int      `yaml:"max_files_size"`
 	DefaultTimeout    string   `yaml:"default_timeout"`
 	MaxTimeout        string   `yaml:"max_timeout"`
 	RunAsNonRoot      bool     `yaml:"run_as_non_root"`
@@ -171,6 +188,137 @@ type PushgatewayConfig struct {
 	URL     string `yaml:"url"`
 }
 
+// ExecutionConfig holds execution lifecycle settings.
+type ExecutionConfig struct {
+	Records           ExecutionRecordsConfig `yaml:"records"`
+	CancelGracePeriod string                 `yaml:"cancel_grace_period"`
+	HeartbeatInterval string                 `yaml:"heartbeat_interval"`
+	SchedulingTimeout string                 `yaml:"scheduling_timeout"`
+	ImagePullTimeout  string                 `yaml:"image_pull_timeout"`
+	OnDisconnect      string                 `yaml:"on_disconnect"`
+	// JanitorInterval is how often expired records, workspaces, logs and
+	// artifacts are looked for.
+	JanitorInterval   string                 `yaml:"janitor_interval"`
+	Output            OutputCaptureConfig    `yaml:"output"`
+	LogStore          LogStoreConfig         `yaml:"log_store"`
+	Artifacts         ArtifactsConfig        `yaml:"artifacts"`
+	Workspaces        WorkspacesConfig       `yaml:"workspaces"`
+	Cache             ResultCacheConfig      `yaml:"cache"`
+}
+
+// ExecutionRecordsConfig holds execution record storage settings.
+type ExecutionRecordsConfig struct {
+	ConfigMapPrefix string `yaml:"configmap_prefix"`
+	// Retention is how long a record is kept after its execution finished.
+	Retention string `yaml:"retention"`
+}
+
+// OutputCaptureConfig bounds the stdout and stderr returned in a response.
+type OutputCaptureConfig struct {
+	HeadBytes int `yaml:"head_bytes"`
+	TailBytes int `yaml:"tail_bytes"`
+}
+
+// LogStoreConfig holds settings for storing full logs of truncated output.
+type LogStoreConfig struct {
+	Type string `yaml:"type"` // filesystem, none
+	Path string `yaml:"path"`
+	// Retention is how long a log is kept.
+	Retention string `yaml:"retention"`
+	// MaxSize bounds the logs kept, in bytes; the oldest are deleted first.
+	MaxSize int64 `yaml:"max_size"`
+}
+
+// ArtifactsConfig holds settings for files collected from the workspace.
+type ArtifactsConfig struct {
+	// MaxTotalSize bounds the artifacts collected from one execution, in
+	// bytes. It cannot exceed 4MB, as artifacts travel through the container
+	// log.
+	MaxTotalSize int                 `yaml:"max_total_size"`
+	Store        ArtifactStoreConfig `yaml:"store"`
+	// Retention is how long a filesystem store keeps an execution's artifacts.
+	Retention string `yaml:"retention"`
+	// MaxStoreSize bounds the artifacts a filesystem store keeps, in bytes;
+	// those of the oldest executions are deleted first.
+	MaxStoreSize int64 `yaml:"max_store_size"`
+}
+
+// ArtifactStoreConfig selects where artifacts are kept. S3 credentials come
+// from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+type ArtifactStoreConfig struct {
+	Type     string `yaml:"type"` // filesystem, s3, none
+	Path     string `yaml:"path"`
+	Endpoint string `yaml:"endpoint"`
+	Bucket   string `yaml:"bucket"`
+	Region   string `yaml:"region"`
+	Prefix   string `yaml:"prefix"`
+}
+
+// WorkspacesConfig holds settings for persistent workspaces, the PVCs that
+// steps of a runbook execution share as /workspace.
+type WorkspacesConfig struct {
+	// StorageClass of the PVCs; empty means the cluster default.
+	StorageClass string `yaml:"storage_class"`
+	Size         string `yaml:"size"`
+	AccessMode   string `yaml:"access_mode"`
+	// TTL is how long a workspace is kept after it was last used.
+	TTL string `yaml:"ttl"`
+}
+
+// ResultCacheConfig bounds the results kept for the "cache" parameter. The
+// cache is held in memory by each executor replica.
+type ResultCacheConfig struct {
+	// MaxTTL caps the ttl a request may ask for.
+	MaxTTL     string `yaml:"max_ttl"`
+	MaxEntries int    `yaml:"max_entries"`
+}
+
+// LanguagesConfig holds the language profiles scripts can be run with.
+type LanguagesConfig struct {
+	// Default is the profile used when neither the request nor the script's
+	// shebang selects one.
+	Default  string                     `yaml:"default"`
+	Profiles map[string]LanguageProfile `yaml:"profiles"`
+}
+
+// LanguageProfile describes how to run scripts in one language.
+type LanguageProfile struct {
+	// Command is the interpreter invocation; the script path is appended.
+	Command []string `yaml:"command"`
+	// ImageRef is the catalog image used when the request names no image.
+	ImageRef string `yaml:"image_ref"`
+	// Extension is given to the script file, e.g. ".ps1".
+	Extension string `yaml:"extension"`
+	// Validator is the script validator for the language (shell, python,
+	// ruby, node, pwsh, none).
+	Validator string `yaml:"validator"`
+	// Interpreters are the interpreter names, as in a shebang or the
+	// interpreter parameter, that select this profile. Version suffixes are
+	// ignored: "python3.12" matches "python".
+	Interpreters []string `yaml:"interpreters"`
+	// InterpreterPaths are the binaries the interpreter parameter may replace
+	// the command's with, besides the command's own.
+	InterpreterPaths []string `yaml:"interpreter_paths"`
+}
+
+// JobProfilesConfig holds named patches for the generated Jobs.
+type JobProfilesConfig struct {
+	// Default is applied when the request selects no profile.
+	Default string `yaml:"default"`
+	// AllowedPaths are the Job fields, as dotted paths such as
+	// "spec.template.spec.dnsConfig", a profile may change. A path covers
+	// everything below it.
+	AllowedPaths []string              `yaml:"allowed_paths"`
+	Profiles     map[string]JobProfile `yaml:"profiles"`
+}
+
+// JobProfile patches the generated batch/v1 Job: first the strategic merge
+// patch, then the JSON patch (RFC 6902).
+type JobProfile struct {
+	StrategicMerge map[string]interface{}   `yaml:"strategic_merge"`
+	JSONPatch      []map[string]interface{} `yaml:"json_patch"`
+}
+
 // Load reads configuration from file and environment.
 func Load() (*Config, error) {
 	cfg := defaultConfig()
@@ -216,6 +364,9 @@ func defaultConfig() *Config {
 				MaxResources: ResourceConfig{
 					Limits: ResourceLimits{CPU: "4000m", Memory: "8Gi", EphemeralStorage: "20Gi"},
 				},
+				Tolerations: TolerationPolicyConfig{
+					DeniedKeys: []string{"node-role.kubernetes.io/control-plane", "node-role.kubernetes.io/master"},
+				},
 			},
 			Image: ImageConfig{
 				DefaultImage:          getEnvOrDefault("DEFAULT_IMAGE", "alpine:latest"),
@@ -242,6 +393,9 @@ func defaultConfig() *Config {
 				},
 				MaxScriptSize:     524288, // 500KB
 				MaxScriptLines:    1000,
+				MaxStdinSize:      262144, // 256KB
+				MaxOutputsSize:    65536,  // 64KB
+				MaxFilesSize:      262144, // 256KB
 				DefaultTimeout:    "5m",
 				MaxTimeout:        "30m",
 				RunAsNonRoot:      true,
@@ -276,6 +430,70 @@ func defaultConfig() *Config {
 				},
 				Pushgateway: PushgatewayConfig{Enabled: false},
 			},
+			Execution: ExecutionConfig{
+				Records: ExecutionRecordsConfig{
+					ConfigMapPrefix: "script-exec-record",
+					Retention:       "168h",
+				},
+				CancelGracePeriod: "30s",
+				HeartbeatInterval: "10s",
+				SchedulingTimeout: "5m",
+				ImagePullTimeout:  "5m",
+				OnDisconnect:      "cancel",
+				JanitorInterval:   "10m",
+				Output: OutputCaptureConfig{
+					HeadBytes: 65536,
+					TailBytes: 65536,
+				},
+				LogStore: LogStoreConfig{
+					Type:      "filesystem",
+					Path:      "/var/lib/script-executor/logs",
+					Retention: "72h",
+					MaxSize:   4 * 1024 * 1024 * 1024, // 4GB
+				},
+				Artifacts: ArtifactsConfig{
+					MaxTotalSize: 4 * 1024 * 1024, // 4MB
+					Store: ArtifactStoreConfig{
+						Type: "filesystem",
+						Path: "/var/lib/script-executor/artifacts",
+					},
+					Retention:    "168h",
+					MaxStoreSize: 8 * 1024 * 1024 * 1024, // 8GB
+				},
+				Workspaces: WorkspacesConfig{
+					Size:            "1Gi",
+					AccessMode:      "ReadWriteOnce",
+					TTL:             "24h",
+				},
+				Cache: ResultCacheConfig{
+					MaxTTL:     "10m",
+					MaxEntries: 1000,
+				},
+			},
+			Languages: LanguagesConfig{
+				Default: "bash",
+				Profiles: map[string]LanguageProfile{
+					"bash":   {Command: []string{"/bin/bash"}, Extension: ".sh", Validator: "shell", Interpreters: []string{"bash"}},
+					"sh":     {Command: []string{"/bin/sh"}, Extension: ".sh", Validator: "shell", Interpreters: []string{"sh", "ash", "dash"}},
+					"python": {Command: []string{"python3", "-u"}, Extension: ".py", Validator: "python", Interpreters: []string{"python"}, InterpreterPaths: []string{"/usr/bin/python3", "/usr/local/bin/python3"}},
+					"ruby":   {Command: []string{"ruby"}, Extension: ".rb", Validator: "ruby", Interpreters: []string{"ruby"}},
+					"node":   {Command: []string{"node"}, Extension: ".js", Validator: "node", Interpreters: []string{"node", "nodejs"}, InterpreterPaths: []string{"/usr/bin/node", "/usr/local/bin/node"}},
+					"pwsh":   {Command: []string{"pwsh", "-NoLogo", "-NoProfile", "-NonInteractive", "-File"}, Extension: ".ps1", Validator: "pwsh", Interpreters: []string{"pwsh"}},
+				},
+			},
+			JobProfiles: JobProfilesConfig{
+				AllowedPaths: []string{
+					"metadata.annotations",
+					"spec.template.metadata.annotations",
+					"spec.template.metadata.labels",
+					"spec.template.spec.dnsConfig",
+					"spec.template.spec.dnsPolicy",
+					"spec.template.spec.hostAliases",
+					"spec.template.spec.priorityClassName",
+					"spec.template.spec.securityContext.seccompProfile",
+				},
+				Profiles: map[string]JobProfile{},
+			},
 		},
 	}
 }
@@ -300,6 +518,12 @@ func mergeConfig(dst, src *Config) {
 		dst.ScriptExecutor.Kubernetes.JobDefaults.BackoffLimit = src.ScriptExecutor.Kubernetes.JobDefaults.BackoffLimit
 		dst.ScriptExecutor.Kubernetes.JobDefaults.ActiveDeadlineSeconds = src.ScriptExecutor.Kubernetes.JobDefaults.ActiveDeadlineSeconds
 	}
+	if len(src.ScriptExecutor.Kubernetes.Tolerations.AllowedKeys) > 0 {
+		dst.ScriptExecutor.Kubernetes.Tolerations.AllowedKeys = src.ScriptExecutor.Kubernetes.Tolerations.AllowedKeys
+	}
+	if len(src.ScriptExecutor.Kubernetes.Tolerations.DeniedKeys) > 0 {
+		dst.ScriptExecutor.Kubernetes.Tolerations.DeniedKeys = src.ScriptExecutor.Kubernetes.Tolerations.DeniedKeys
+	}
 	if src.ScriptExecutor.Image.DefaultImage != "" {
 		dst.ScriptExecutor.Image.DefaultImage = src.ScriptExecutor.Image.DefaultImage
 	}
@@ -318,6 +542,15 @@ func mergeConfig(dst, src *Config) {
 	if src.ScriptExecutor.Security.MaxScriptLines != 0 {
 		dst.ScriptExecutor.Security.MaxScriptLines = src.ScriptExecutor.Security.MaxScriptLines
 	}
+	if src.ScriptExecutor.Security.MaxStdinSize != 0 {
+		dst.ScriptExecutor.Security.MaxStdinSize = src.ScriptExecutor.Security.MaxStdinSize
+	}
+	if src.ScriptExecutor.Security.MaxOutputsSize != 0 {
+		dst.ScriptExecutor.Security.MaxOutputsSize = src.ScriptExecutor.Security.MaxOutputsSize
+	}
+	if src.ScriptExecutor.Security.MaxFilesSize != 0 {
+		dst.ScriptExecutor.Security.MaxFilesSize = src.ScriptExecutor.Security.MaxFilesSize
+	}
 	if src.ScriptExecutor.Approval.Storage.ConfigMapName != "" {
 		dst.ScriptExecutor.Approval.Storage.ConfigMapName = src.ScriptExecutor.Approval.Storage.ConfigMapName
 	}
@@ -330,6 +563,93 @@ func mergeConfig(dst, src *Config) {
 	if src.ScriptExecutor.Monitoring.Metrics.Port != 0 {
 		dst.ScriptExecutor.Monitoring.Metrics.Port = src.ScriptExecutor.Monitoring.Metrics.Port
 	}
+	if src.ScriptExecutor.Execution.Records.ConfigMapPrefix != "" {
+		dst.ScriptExecutor.Execution.Records.ConfigMapPrefix = src.ScriptExecutor.Execution.Records.ConfigMapPrefix
+	}
+	if src.ScriptExecutor.Execution.Records.Retention != "" {
+		dst.ScriptExecutor.Execution.Records.Retention = src.ScriptExecutor.Execution.Records.Retention
+	}
+	if src.ScriptExecutor.Execution.JanitorInterval != "" {
+		dst.ScriptExecutor.Execution.JanitorInterval = src.ScriptExecutor.Execution.JanitorInterval
+	}
+	if src.ScriptExecutor.Execution.CancelGracePeriod != "" {
+		dst.ScriptExecutor.Execution.CancelGracePeriod = src.ScriptExecutor.Execution.CancelGracePeriod
+	}
+	if src.ScriptExecutor.Execution.HeartbeatInterval != "" {
+		dst.ScriptExecutor.Execution.HeartbeatInterval = src.ScriptExecutor.Execution.HeartbeatInterval
+	}
+	if src.ScriptExecutor.Execution.SchedulingTimeout != "" {
+		dst.ScriptExecutor.Execution.SchedulingTimeout = src.ScriptExecutor.Execution.SchedulingTimeout
+	}
+	if src.ScriptExecutor.Execution.ImagePullTimeout != "" {
+		dst.ScriptExecutor.Execution.ImagePullTimeout = src.ScriptExecutor.Execution.ImagePullTimeout
+	}
+	if src.ScriptExecutor.Execution.OnDisconnect != "" {
+		dst.ScriptExecutor.Execution.OnDisconnect = src.ScriptExecutor.Execution.OnDisconnect
+	}
+	if src.ScriptExecutor.Execution.Output.HeadBytes != 0 {
+		dst.ScriptExecutor.Execution.Output.HeadBytes = src.ScriptExecutor.Execution.Output.HeadBytes
+	}
+	if src.ScriptExecutor.Execution.Output.TailBytes != 0 {
+		dst.ScriptExecutor.Execution.Output.TailBytes = src.ScriptExecutor.Execution.Output.TailBytes
+	}
+	if src.ScriptExecutor.Execution.LogStore.Type != "" {
+		dst.ScriptExecutor.Execution.LogStore.Type = src.ScriptExecutor.Execution.LogStore.Type
+	}
+	if src.ScriptExecutor.Execution.LogStore.Path != "" {
+		dst.ScriptExecutor.Execution.LogStore.Path = src.ScriptExecutor.Execution.LogStore.Path
+	}
+	if src.ScriptExecutor.Execution.LogStore.Retention != "" {
+		dst.ScriptExecutor.Execution.LogStore.Retention = src.ScriptExecutor.Execution.LogStore.Retention
+	}
+	if src.ScriptExecutor.Execution.LogStore.MaxSize != 0 {
+		dst.ScriptExecutor.Execution.LogStore.MaxSize = src.ScriptExecutor.Execution.LogStore.MaxSize
+	}
+	if src.ScriptExecutor.Execution.Artifacts.MaxTotalSize != 0 {
+		dst.ScriptExecutor.Execution.Artifacts.MaxTotalSize = src.ScriptExecutor.Execution.Artifacts.MaxTotalSize
+	}
+	if src.ScriptExecutor.Execution.Artifacts.Store.Type != "" {
+		dst.ScriptExecutor.Execution.Artifacts.Store = src.ScriptExecutor.Execution.Artifacts.Store
+	}
+	if src.ScriptExecutor.Execution.Artifacts.Retention != "" {
+		dst.ScriptExecutor.Execution.Artifacts.Retention = src.ScriptExecutor.Execution.Artifacts.Retention
+	}
+	if src.ScriptExecutor.Execution.Artifacts.MaxStoreSize != 0 {
+		dst.ScriptExecutor.Execution.Artifacts.MaxStoreSize = src.ScriptExecutor.Execution.Artifacts.MaxStoreSize
+	}
+	if src.ScriptExecutor.Execution.Workspaces.StorageClass != "" {
+		dst.ScriptExecutor.Execution.Workspaces.StorageClass = src.ScriptExecutor.Execution.Workspaces.StorageClass
+	}
+	if src.ScriptExecutor.Execution.Workspaces.Size != "" {
+		dst.ScriptExecutor.Execution.Workspaces.Size = src.ScriptExecutor.Execution.Workspaces.Size
+	}
+	if src.ScriptExecutor.Execution.Workspaces.AccessMode != "" {
+		dst.ScriptExecutor.Execution.Workspaces.AccessMode = src.ScriptExecutor.Execution.Workspaces.AccessMode
+	}
+	if src.ScriptExecutor.Execution.Workspaces.TTL != "" {
+		dst.ScriptExecutor.Execution.Workspaces.TTL = src.ScriptExecutor.Execution.Workspaces.TTL
+	}
+	if src.ScriptExecutor.Execution.Cache.MaxTTL != "" {
+		dst.ScriptExecutor.Execution.Cache.MaxTTL = src.ScriptExecutor.Execution.Cache.MaxTTL
+	}
+	if src.ScriptExecutor.Execution.Cache.MaxEntries != 0 {
+		dst.ScriptExecutor.Execution.Cache.MaxEntries = src.ScriptExecutor.Execution.Cache.MaxEntries
+	}
+	if src.ScriptExecutor.Languages.Default != "" {
+		dst.ScriptExecutor.Languages.Default = src.ScriptExecutor.Languages.Default
+	}
+	for name, profile := range src.ScriptExecutor.Languages.Profiles {
+		dst.ScriptExecutor.Languages.Profiles[name] = profile
+	}
+	if src.ScriptExecutor.JobProfiles.Default != "" {
+		dst.ScriptExecutor.JobProfiles.Default = src.ScriptExecutor.JobProfiles.Default
+	}
+	if len(src.ScriptExecutor.JobProfiles.AllowedPaths) > 0 {
+		dst.ScriptExecutor.JobProfiles.AllowedPaths = src.ScriptExecutor.JobProfiles.AllowedPaths
+	}
+	for name, profile := range src.ScriptExecutor.JobProfiles.Profiles {
+		dst.ScriptExecutor.JobProfiles.Profiles[name] = profile
+	}
 }
 
 func applyEnvOverrides(cfg *Config) {
@@ -370,3 +690,79 @@ func (c *Config) MaxTimeout() time.Duration {
 	}
 	return d
 }
+
+// CancelGracePeriod returns the grace period given to pods of a cancelled execution.
+func (c *Config) CancelGracePeriod() time.Duration {
+	d, err := time.ParseDuration(c.ScriptExecutor.Execution.CancelGracePeriod)
+	if err != nil {
+		return 30 * time.Second
+	}
+	return d
+}
+
+// HeartbeatInterval returns how long ExecuteStream may stay silent before it
+// sends a heartbeat.
+func (c *Config) HeartbeatInterval() time.Duration {
+	d, err := time.ParseDuration(c.ScriptExecutor.Execution.HeartbeatInterval)
+	if err != nil || d <= 0 {
+		return 10 * time.Second
+	}
+	return d
+}
+
+// WorkspaceTTL returns how long a persistent workspace is kept after its last
+// use.
+func (c *Config) WorkspaceTTL() time.Duration {
+	d, err := time.ParseDuration(c.ScriptExecutor.Execution.Workspaces.TTL)
+	if err != nil || d <= 0 {
+		return 24 * time.Hour
+	}
+	return d
+}
+
+// JanitorInterval returns how often expired records, workspaces and stored
+// logs and artifacts are deleted.
+func (c *Config) JanitorInterval() time.Duration {
+	d, err := time.ParseDuration(c.ScriptExecutor.Execution.JanitorInterval)
+	if err != nil || d <= 0 {
+		return 10 * time.Minute
+	}
+	return d
+}
+
+// RecordRetention returns how long execution records are kept after the
+// execution finished.
+func (c *Config) RecordRetention() time.Duration {
+	d, err := time.ParseDuration(c.ScriptExecutor.Execution.Records.Retention)
+	if err != nil || d <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return d
+}
+
+// LogRetention returns how long a stored log is kept.
+func (c *Config) LogRetention() time.Duration {
+	d, err := time.ParseDuration(c.ScriptExecutor.Execution.LogStore.Retention)
+	if err != nil || d <= 0 {
+		return 72 * time.Hour
+	}
+	return d
+}
+
+// ArtifactRetention returns how long stored artifacts are kept.
+func (c *Config) ArtifactRetention() time.Duration {
+	d, err := time.ParseDuration(c.ScriptExecutor.Execution.Artifacts.Retention)
+	if err != nil || d <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return d
+}
+
+// CacheMaxTTL returns the longest a cached result may be reused.
+func (c *Config) CacheMaxTTL() time.Duration {
+	d, err := time.ParseDuration(c.ScriptExecutor.Execution.Cache.MaxTTL)
+	if err != nil || d <= 0 {
+		return 10 * time.Minute
+	}
+	return d
+}
@@ -0,0 +1,128 @@
+package execution
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/config"
+)
+
+// maxArtifactsLimit caps execution.artifacts.max_total_size, and applies when
+// it is unset. Artifacts travel base64-encoded through the container log, so
+// this keeps them, with the script's output, well below the kubelet's default
+// log rotation size of 10Mi; a rotated log would lose them.
+const maxArtifactsLimit = 4 * 1024 * 1024
+
+// artifactChunkBytes is the maximum size of an ArtifactChunk sent by
+// GetArtifact.
+const artifactChunkBytes = 64 * 1024
+
+// ErrArtifactStoreDisabled is returned when no artifact store is configured.
+var ErrArtifactStoreDisabled = errors.New("artifact store is not configured")
+
+func maxArtifactsSize(cfg *config.Config) int {
+	if n := cfg.ScriptExecutor.Execution.Artifacts.MaxTotalSize; n > 0 && n < maxArtifactsLimit {
+		return n
+	}
+	return maxArtifactsLimit
+}
+
+// artifactPatternError returns why an artifacts glob is invalid, or "".
+func artifactPatternError(pattern string) string {
+	if desc := workspacePathError(pattern); desc != "" {
+		return desc
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "invalid glob"
+	}
+	return ""
+}
+
+// storeArtifacts checksums the collected artifacts, stores them under the
+// execution ID and returns their entries for the response output. Every
+// artifact that could not be collected or stored is also reported in a
+// DetailedError.
+func (m *Manager) storeArtifacts(ctx context.Context, execCtx *Context, result *Result) ([]interface{}, error) {
+	if len(result.Artifacts) == 0 {
+		return nil, nil
+	}
+	var entries []interface{}
+	var violations []*executorv1.FieldViolation
+	seen := map[string]bool{}
+	for _, a := range result.Artifacts {
+		name := path.Clean(a.Path)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		entry := map[string]interface{}{"path": name, "size": float64(a.Size)}
+		if a.Error == "" && m.artifacts == nil {
+			a.Error = ErrArtifactStoreDisabled.Error()
+		}
+		if a.Error == "" {
+			sum := sha256.Sum256(a.Data)
+			entry["sha256"] = hex.EncodeToString(sum[:])
+			ref, err := m.artifacts.Put(ctx, execCtx.ExecutionID+"/"+name, a.Data)
+			if err != nil {
+				a.Error = err.Error()
+			} else {
+				entry["ref"] = ref
+			}
+		}
+		a.Data = nil
+		if a.Error != "" {
+			entry["error"] = a.Error
+			violations = append(violations, &executorv1.FieldViolation{
+				Field:       "artifacts",
+				Description: fmt.Sprintf("%s: %s", name, a.Error),
+			})
+		}
+		entries = append(entries, entry)
+	}
+	if len(violations) > 0 {
+		return entries, &DetailedError{
+			Code:       CodeArtifactsFailed,
+			Message:    fmt.Sprintf("collect artifacts: %s", violations[0].Description),
+			Violations: violations,
+		}
+	}
+	return entries, nil
+}
+
+// GetArtifact sends the stored artifact referenced by ref in chunks.
+func (m *Manager) GetArtifact(ctx context.Context, ref string, send func(*executorv1.ArtifactChunk) error) error {
+	if m.artifacts == nil {
+		return ErrArtifactStoreDisabled
+	}
+	rc, err := m.artifacts.Open(ctx, ref)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	buf := make([]byte, artifactChunkBytes)
+	for {
+		n, err := io.ReadFull(rc, buf)
+		if n > 0 {
+			if serr := send(&executorv1.ArtifactChunk{Data: append([]byte(nil), buf[:n]...)}); serr != nil {
+				return serr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package execution
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/audit"
+	"github.com/rakeshavasarala/script-executor/internal/config"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// outputParamNames are the request parameters that shape the response without
+// being part of the Context the Job is built from.
+var outputParamNames = []string{"output_format", "extract", "success_criteria", "artifacts"}
+
+// resultCache holds the successful responses of executions that asked to be
+// cached, by cache key, and the executions still running for a key.
+type resultCache struct {
+	mu         sync.Mutex
+	entries    map[string]cacheEntry
+	maxEntries int
+	maxAge     time.Duration
+	// running is closed when the execution running for a key finishes
+	running map[string]chan struct{}
+}
+
+type cacheEntry struct {
+	executionID string
+	resp        *executorv1.ExecuteResponse
+	finishedAt  time.Time
+}
+
+func newResultCache(cfg *config.Config) *resultCache {
+	maxEntries := cfg.ScriptExecutor.Execution.Cache.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &resultCache{
+		entries:    make(map[string]cacheEntry),
+		maxEntries: maxEntries,
+		maxAge:     cfg.CacheMaxTTL(),
+		running:    make(map[string]chan struct{}),
+	}
+}
+
+// claim returns the entry for key if it finished within ttl. Otherwise it
+// returns the channel of an identical execution that is still running, or,
+// when there is none, nil: the caller runs the execution and calls release.
+func (c *resultCache) claim(key string, ttl time.Duration) (cacheEntry, bool, <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok && time.Since(e.finishedAt) <= ttl {
+		return e, true, nil
+	}
+	if running, ok := c.running[key]; ok {
+		return cacheEntry{}, false, running
+	}
+	c.running[key] = make(chan struct{})
+	return cacheEntry{}, false, nil
+}
+
+// release wakes the requests waiting for the execution running for key. Put
+// a successful result first, so they find it.
+func (c *resultCache) release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if running, ok := c.running[key]; ok {
+		close(running)
+		delete(c.running, key)
+	}
+}
+
+// put stores an entry, first dropping entries no request can use anymore and,
+// when still full, the oldest one.
+func (c *resultCache) put(key string, e cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		oldestKey, oldest := "", time.Now()
+		for k, v := range c.entries {
+			if time.Since(v.finishedAt) > c.maxAge {
+				delete(c.entries, k)
+				continue
+			}
+			if v.finishedAt.Before(oldest) {
+				oldestKey, oldest = k, v.finishedAt
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			delete(c.entries, oldestKey)
+		}
+	}
+	c.entries[key] = e
+}
+
+// parseCache reads the cache parameter, {ttl}: how old a result of an
+// identical execution may be to be returned instead of running the script.
+func parseCache(params *structpb.Struct, cfg *config.Config) (time.Duration, error) {
+	m := getMap(params, "cache")
+	if m == nil || len(m.Fields) == 0 {
+		return 0, nil
+	}
+	cacheError := func(desc string) error {
+		return &DetailedError{
+			Code:    CodeInvalidParameter,
+			Message: "invalid cache: ttl " + desc,
+			Violations: []*executorv1.FieldViolation{
+				{Field: "cache.ttl", Description: desc},
+			},
+		}
+	}
+	ttl, err := parseDuration(getString(m, "ttl", ""))
+	if err != nil {
+		return 0, cacheError(fmt.Sprintf("is not a duration: %v", err))
+	}
+	if ttl <= 0 {
+		return 0, cacheError("must be a positive duration")
+	}
+	if max := cfg.CacheMaxTTL(); ttl > max {
+		return 0, cacheError(fmt.Sprintf("must be at most %s", max))
+	}
+	return ttl, nil
+}
+
+// cacheKey fingerprints everything that decides what an execution returns:
+// the script (for script_path, its path and the approved-scripts version),
+// image and command, inputs, plain environment, files, the workspace and the
+// output parameters. Referenced Secrets and ConfigMaps contribute their name
+// and resourceVersion, never their data, so the key changes when they do.
+func (m *Manager) cacheKey(ctx context.Context, execContext *Context, params *structpb.Struct) (string, error) {
+	hash := func(data []byte) string {
+		h := sha256.Sum256(data)
+		return hex.EncodeToString(h[:])
+	}
+
+	secrets := map[string]bool{}
+	configMaps := map[string]bool{}
+	for _, ref := range execContext.EnvFromSecret {
+		secrets[ref.SecretName] = true
+	}
+	for _, name := range execContext.SecretEnvAll {
+		secrets[name] = true
+	}
+	for _, v := range execContext.VolumesFromSecret {
+		secrets[v.SecretName] = true
+	}
+	for _, ref := range execContext.EnvFromConfigMap {
+		configMaps[ref.ConfigMapName] = true
+	}
+	for _, name := range execContext.ConfigMapEnvAll {
+		configMaps[name] = true
+	}
+	for _, v := range execContext.VolumesFromConfigMap {
+		configMaps[v.ConfigMapName] = true
+	}
+	namespace := m.config.ScriptExecutor.Kubernetes.Namespace
+	versions := map[string]string{}
+	for _, name := range sortedKeys(secrets) {
+		s, err := m.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			versions["secret/"+name] = ""
+		case err != nil:
+			return "", fmt.Errorf("get secret %s: %w", name, err)
+		default:
+			versions["secret/"+name] = s.ResourceVersion
+		}
+	}
+	for _, name := range sortedKeys(configMaps) {
+		cm, err := m.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			versions["configmap/"+name] = ""
+		case err != nil:
+			return "", fmt.Errorf("get configmap %s: %w", name, err)
+		default:
+			versions["configmap/"+name] = cm.ResourceVersion
+		}
+	}
+
+	files := make([]map[string]interface{}, 0, len(execContext.Files))
+	for _, f := range execContext.Files {
+		files = append(files, map[string]interface{}{
+			"path":       f.Path,
+			"sha256":     hash(f.Content),
+			"executable": f.Executable,
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i]["path"].(string) < files[j]["path"].(string) })
+	var tools, sidecars []string
+	for _, t := range execContext.Tools {
+		tools = append(tools, t.Name+"="+t.Image)
+	}
+	for _, s := range execContext.Sidecars {
+		sidecars = append(sidecars, s.Name+"="+s.Image)
+	}
+	output := map[string]interface{}{}
+	for _, name := range outputParamNames {
+		if v, ok := params.GetFields()[name]; ok {
+			output[name] = v.AsInterface()
+		}
+	}
+
+	var scriptPath, scriptVersion, workspace string
+	if src := execContext.ScriptSource; src != nil {
+		scriptPath, scriptVersion = src.Path, src.Version
+	}
+	if execContext.Workspace != nil {
+		workspace = execContext.Workspace.Claim
+	}
+
+	// encoding/json sorts map keys, so equal fingerprints encode equally
+	data, err := json.Marshal(map[string]interface{}{
+		"script_hash":            execContext.ScriptHash,
+		"script_path":            scriptPath,
+		"script_version":         scriptVersion,
+		"image":                  execContext.Image,
+		"command":                append([]string{execContext.Interpreter}, execContext.InterpreterArgs...),
+		"args":                   execContext.Args,
+		"working_dir":            execContext.WorkingDir,
+		"stdin":                  hash([]byte(execContext.Stdin)),
+		"files":                  files,
+		"inputs":                 execContext.Inputs,
+		"env":                    execContext.Env,
+		"env_from_secret":        execContext.EnvFromSecret,
+		"env_from_configmap":     execContext.EnvFromConfigMap,
+		"volumes_from_secret":    execContext.VolumesFromSecret,
+		"volumes_from_configmap": execContext.VolumesFromConfigMap,
+		"resource_versions":      versions,
+		"service_account":        execContext.ServiceAccount,
+		"tools":                  tools,
+		"sidecars":               sidecars,
+		"profile":                execContext.Profile,
+		"workspace":              workspace,
+		"output":                 output,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode cache key: %w", err)
+	}
+	return hash(data), nil
+}
+
+// cachedResult returns a recent result of an identical execution, marked
+// cached, or nil when the script has to run. While an identical execution is
+// running, it waits for that one's result if wait is set; if it fails, one of
+// the waiting requests runs the script itself. Without wait, the script runs
+// uncached instead. The key is kept on the context of the request that runs,
+// so its result is cached for later requests and releaseCached wakes those
+// waiting for it.
+func (m *Manager) cachedResult(ctx context.Context, p *prepared, params *structpb.Struct, wait bool) (*executorv1.ExecuteResponse, error) {
+	execContext := p.context
+	key, err := m.cacheKey(ctx, execContext, params)
+	if err != nil {
+		// Run uncached rather than fail a request that would work without it
+		log.Printf("Result cache disabled for execution %s: %v", execContext.ExecutionID, err)
+		return nil, nil
+	}
+	for {
+		e, ok, running := m.results.claim(key, execContext.CacheTTL)
+		if ok {
+			return cachedResponse(e, p.startTime), nil
+		}
+		if running == nil {
+			execContext.CacheKey = key
+			return nil, nil
+		}
+		if !wait {
+			return nil, nil
+		}
+		select {
+		case <-running:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for identical execution: %w", ctx.Err())
+		}
+	}
+}
+
+// releaseCached caches the response of an execution that ran for a cache key,
+// if it succeeded, and wakes the requests waiting for it. resp is nil when the
+// execution ended without a result.
+func (m *Manager) releaseCached(execContext *Context, resp *executorv1.ExecuteResponse) {
+	key := execContext.CacheKey
+	if key == "" {
+		return
+	}
+	if resp.GetStatus() == executorv1.ExecuteResponse_STATUS_SUCCEEDED {
+		m.results.put(key, cacheEntry{
+			executionID: execContext.ExecutionID,
+			resp:        resp,
+			finishedAt:  time.Now(),
+		})
+	}
+	m.results.release(key)
+}
+
+// cachedResponse returns a copy of a cached response, marked cached.
+func cachedResponse(e cacheEntry, startTime time.Time) *executorv1.ExecuteResponse {
+	resp := proto.Clone(e.resp).(*executorv1.ExecuteResponse)
+	if resp.Output == nil {
+		resp.Output = &structpb.Struct{}
+	}
+	if resp.Output.Fields == nil {
+		resp.Output.Fields = make(map[string]*structpb.Value)
+	}
+	resp.Output.Fields["cached"] = structpb.NewBoolValue(true)
+	resp.Output.Fields["cached_execution_id"] = structpb.NewStringValue(e.executionID)
+	resp.Output.Fields["cached_at"] = structpb.NewStringValue(e.finishedAt.UTC().Format(time.RFC3339))
+	resp.Duration = durationpbOf(time.Since(startTime))
+	return resp
+}
+
+// auditCached logs a request answered from the result cache, so the audit log
+// still shows who got which script's result.
+func (m *Manager) auditCached(execContext *Context, resp *executorv1.ExecuteResponse) {
+	if m.auditLog == nil {
+		return
+	}
+	fields := resp.GetOutput().GetFields()
+	m.auditLog.LogExecution(&audit.Execution{
+		ExecutionID: execContext.ExecutionID,
+		User:        execContext.User,
+		RunbookID:   execContext.RunbookID,
+		ScriptHash:  execContext.ScriptHash,
+		Source:      execContext.ScriptSource,
+		Succeeded:   true,
+		Duration:    resp.GetDuration().AsDuration(),
+		ExitCode:    int(fields["exit_code"].GetNumberValue()),
+		Stdin:       execContext.Stdin,
+		Files:       auditFiles(execContext.Files),
+		Inputs:      execContext.Inputs,
+		CachedFrom:  fields["cached_execution_id"].GetStringValue(),
+	})
+}
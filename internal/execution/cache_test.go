@@ -0,0 +1,169 @@
+package execution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/script"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCacheKey(t *testing.T) {
+	cfg := testConfig(t)
+	m := &Manager{config: cfg, client: fake.NewSimpleClientset()}
+	base := func() *Context {
+		return &Context{
+			ScriptHash:   "abc",
+			ScriptSource: &script.Source{Type: script.SourcePath, Path: "/scripts/a.sh", Version: "1"},
+			Image:        "busybox:1.36",
+			Interpreter:  "/bin/sh",
+		}
+	}
+	params := &structpb.Struct{}
+	baseKey, err := m.cacheKey(context.Background(), base(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		mutate func(*Context)
+	}{
+		{"script path", func(c *Context) { c.ScriptSource.Path = "/scripts/b.sh" }},
+		{"approved scripts version", func(c *Context) { c.ScriptSource.Version = "2" }},
+		{"workspace", func(c *Context) { c.Workspace = &Workspace{Key: "k", Claim: workspaceClaim("rb-1", "k")} }},
+		{"image", func(c *Context) { c.Image = "busybox:1.37" }},
+		{"env", func(c *Context) { c.Env = map[string]string{"A": "1"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := base()
+			tt.mutate(c)
+			key, err := m.cacheKey(context.Background(), c, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key == baseKey {
+				t.Error("cache key did not change")
+			}
+		})
+	}
+}
+
+func TestCachedResultWaitsForRunningExecution(t *testing.T) {
+	cfg := testConfig(t)
+	succeeded := &executorv1.ExecuteResponse{Status: executorv1.ExecuteResponse_STATUS_SUCCEEDED}
+	failed := &executorv1.ExecuteResponse{Status: executorv1.ExecuteResponse_STATUS_FAILED}
+	tests := []struct {
+		name       string
+		leaderResp *executorv1.ExecuteResponse
+		wantCached bool
+	}{
+		{name: "leader succeeds", leaderResp: succeeded, wantCached: true},
+		{name: "leader fails", leaderResp: failed},
+		{name: "leader never launched", leaderResp: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{config: cfg, client: fake.NewSimpleClientset(), results: newResultCache(cfg)}
+			prepare := func(id string) *prepared {
+				return &prepared{
+					context:   &Context{ExecutionID: id, ScriptHash: "abc", Image: "busybox:1.36", CacheTTL: time.Minute},
+					startTime: time.Now(),
+				}
+			}
+
+			leader := prepare("exec-1")
+			if resp, err := m.cachedResult(context.Background(), leader, &structpb.Struct{}, true); resp != nil || err != nil {
+				t.Fatalf("first request = %v, %v; want it to run", resp, err)
+			}
+			if leader.context.CacheKey == "" {
+				t.Fatal("first request has no cache key")
+			}
+
+			type result struct {
+				resp *executorv1.ExecuteResponse
+				err  error
+			}
+			follower := prepare("exec-2")
+			done := make(chan result, 1)
+			go func() {
+				resp, err := m.cachedResult(context.Background(), follower, &structpb.Struct{}, true)
+				done <- result{resp, err}
+			}()
+			select {
+			case r := <-done:
+				t.Fatalf("identical request returned %v, %v while the first one runs", r.resp, r.err)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			m.releaseCached(leader.context, tt.leaderResp)
+			var r result
+			select {
+			case r = <-done:
+			case <-time.After(time.Second):
+				t.Fatal("identical request still waiting after the first one finished")
+			}
+			if r.err != nil {
+				t.Fatal(r.err)
+			}
+			if tt.wantCached {
+				if r.resp == nil || !r.resp.Output.Fields["cached"].GetBoolValue() ||
+					r.resp.Output.Fields["cached_execution_id"].GetStringValue() != "exec-1" {
+					t.Errorf("identical request = %v, want the cached result of exec-1", r.resp)
+				}
+				return
+			}
+			if r.resp != nil || follower.context.CacheKey != leader.context.CacheKey {
+				t.Errorf("identical request = %v, want it to run after the first one failed", r.resp)
+			}
+		})
+	}
+}
+
+func TestCachedResultWaitCancelled(t *testing.T) {
+	cfg := testConfig(t)
+	m := &Manager{config: cfg, client: fake.NewSimpleClientset(), results: newResultCache(cfg)}
+	ctx := &Context{ExecutionID: "exec-1", ScriptHash: "abc", CacheTTL: time.Minute}
+	if _, err := m.cachedResult(context.Background(), &prepared{context: ctx}, &structpb.Struct{}, true); err != nil {
+		t.Fatal(err)
+	}
+	waitCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	other := &Context{ExecutionID: "exec-2", ScriptHash: "abc", CacheTTL: time.Minute}
+	if _, err := m.cachedResult(waitCtx, &prepared{context: other}, &structpb.Struct{}, true); err == nil {
+		t.Error("waiting request outlived its context")
+	}
+}
+
+func TestCachedResultNoWait(t *testing.T) {
+	cfg := testConfig(t)
+	m := &Manager{config: cfg, client: fake.NewSimpleClientset(), results: newResultCache(cfg)}
+	ctx := &Context{ExecutionID: "exec-1", ScriptHash: "abc", CacheTTL: time.Minute}
+	if _, err := m.cachedResult(context.Background(), &prepared{context: ctx}, &structpb.Struct{}, false); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.CacheKey == "" {
+		t.Fatal("first request did not claim the cache key")
+	}
+
+	// An identical request that may not wait runs on its own, uncached
+	other := &Context{ExecutionID: "exec-2", ScriptHash: "abc", CacheTTL: time.Minute}
+	resp, err := m.cachedResult(context.Background(), &prepared{context: other}, &structpb.Struct{}, false)
+	if resp != nil || err != nil {
+		t.Fatalf("cachedResult() = %v, %v, want to run", resp, err)
+	}
+	if other.CacheKey != "" {
+		t.Errorf("CacheKey = %q, want the request to run uncached", other.CacheKey)
+	}
+
+	// Once the first one succeeded, its result is returned without waiting
+	m.releaseCached(ctx, &executorv1.ExecuteResponse{Status: executorv1.ExecuteResponse_STATUS_SUCCEEDED})
+	third := &Context{ExecutionID: "exec-3", ScriptHash: "abc", CacheTTL: time.Minute}
+	resp, err = m.cachedResult(context.Background(), &prepared{context: third}, &structpb.Struct{}, false)
+	if err != nil || resp.GetOutput().GetFields()["cached"].GetBoolValue() != true {
+		t.Errorf("cachedResult() = %v, %v, want the cached result", resp, err)
+	}
+}
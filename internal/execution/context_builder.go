@@ -2,7 +2,9 @@ package execution
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
 	"github.com/rakeshavasarala/script-executor/internal/config"
@@ -16,6 +18,7 @@ import (
 func BuildContext(
 	params *structpb.Struct,
 	execCtx *executorv1.ExecutionContext,
+	reqTimeout time.Duration,
 	scriptContent string,
 	source *script.Source,
 	scriptHash string,
@@ -31,7 +34,6 @@ func BuildContext(
 		Image:        image,
 		ImagePullPolicy: corev1.PullPolicy(parsePullPolicy(getString(params, "image_pull_policy", ""))),
 		ImagePullSecret: imagePullSecret,
-		Interpreter:  getString(params, "interpreter", "/bin/bash"),
 		WorkingDir:   getString(params, "working_dir", "/workspace"),
 		Stdin:        getString(params, "stdin", ""),
 		Env:          make(map[string]string),
@@ -56,6 +58,62 @@ func BuildContext(
 		ctx.User = execCtx.User
 	}
 
+	// stdin is delivered through a Secret, so keep it well below the 1MB object limit
+	if maxStdin := cfg.ScriptExecutor.Security.MaxStdinSize; maxStdin > 0 && len(ctx.Stdin) > maxStdin {
+		return nil, &DetailedError{
+			Code:    CodeInvalidParameter,
+			Message: fmt.Sprintf("stdin too large: %d bytes (max: %d)", len(ctx.Stdin), maxStdin),
+			Violations: []*executorv1.FieldViolation{
+				{Field: "stdin", Description: fmt.Sprintf("must be at most %d bytes", maxStdin)},
+			},
+		}
+	}
+
+	// files, delivered through the same Secret
+	files, violations := parseFiles(params, cfg.ScriptExecutor.Security.MaxFilesSize)
+	if len(violations) > 0 {
+		return nil, &DetailedError{
+			Code:       CodeInvalidParameter,
+			Message:    fmt.Sprintf("invalid files: %s: %s", violations[0].Field, violations[0].Description),
+			Violations: violations,
+		}
+	}
+	ctx.Files = files
+
+	// A bundle is unpacked into /workspace alongside the input files
+	if source != nil && source.Bundle != nil {
+		taken := make(map[string]bool, len(files))
+		for _, f := range files {
+			taken[f.Path] = true
+		}
+		for _, f := range source.Bundle.Files {
+			if taken[f.Path] {
+				return nil, &DetailedError{
+					Code:    CodeInvalidParameter,
+					Message: fmt.Sprintf("file %s conflicts with the script bundle", f.Path),
+					Violations: []*executorv1.FieldViolation{
+						{Field: "files." + f.Path, Description: "is also a file of the script bundle"},
+					},
+				}
+			}
+			ctx.Files = append(ctx.Files, InputFile{Path: f.Path, Content: f.Content, Executable: f.Executable})
+		}
+	}
+
+	// Named inputs, checked against the declared types
+	inputs, violations := parseInputs(params, source)
+	if len(violations) > 0 {
+		return nil, &DetailedError{
+			Code:       CodeInvalidParameter,
+			Message:    fmt.Sprintf("invalid inputs: %s: %s", violations[0].Field, violations[0].Description),
+			Violations: violations,
+		}
+	}
+	ctx.Inputs = inputs
+	if err := checkInputSecretSize(ctx); err != nil {
+		return nil, err
+	}
+
 	// Args
 	ctx.Args = getStringSlice(params, "args")
 	if ctx.Args == nil {
@@ -72,6 +130,57 @@ func BuildContext(
 	if ctx.Timeout == 0 {
 		ctx.Timeout = cfg.DefaultTimeout()
 	}
+	if reqTimeout > 0 {
+		// The request's own timeout field wins over the parameter
+		ctx.Timeout = reqTimeout
+	}
+	schedStr := getString(params, "scheduling_timeout", cfg.ScriptExecutor.Execution.SchedulingTimeout)
+	if ctx.SchedulingTimeout, err = parseDuration(schedStr); err != nil {
+		return nil, fmt.Errorf("invalid scheduling_timeout %q: %w", schedStr, err)
+	}
+	pullStr := getString(params, "image_pull_timeout", cfg.ScriptExecutor.Execution.ImagePullTimeout)
+	if ctx.ImagePullTimeout, err = parseDuration(pullStr); err != nil {
+		return nil, fmt.Errorf("invalid image_pull_timeout %q: %w", pullStr, err)
+	}
+	// Each phase is capped by security.max_timeout, and so is the Job as a
+	// whole, since together they set how long the Job may hold its pod
+	maxTimeout := cfg.MaxTimeout()
+	ctx.MaxTimeout = maxTimeout
+	for _, t := range []struct {
+		field string
+		value time.Duration
+	}{
+		{"timeout", ctx.Timeout},
+		{"scheduling_timeout", ctx.SchedulingTimeout},
+		{"image_pull_timeout", ctx.ImagePullTimeout},
+	} {
+		if t.value < 0 || t.value > maxTimeout {
+			return nil, &DetailedError{
+				Code:    CodeInvalidParameter,
+				Message: fmt.Sprintf("invalid %s %v: must be at most %v", t.field, t.value, maxTimeout),
+				Violations: []*executorv1.FieldViolation{
+					{Field: t.field, Description: fmt.Sprintf("must be between 0 and %v", maxTimeout)},
+				},
+			}
+		}
+	}
+
+	// Disconnect policy
+	ctx.OnDisconnect = getString(params, "on_disconnect", cfg.ScriptExecutor.Execution.OnDisconnect)
+	if ctx.OnDisconnect != OnDisconnectCancel && ctx.OnDisconnect != OnDisconnectDetach {
+		return nil, &DetailedError{
+			Code:    CodeInvalidParameter,
+			Message: fmt.Sprintf("invalid on_disconnect %q", ctx.OnDisconnect),
+			Violations: []*executorv1.FieldViolation{
+				{Field: "on_disconnect", Description: "must be \"cancel\" or \"detach\""},
+			},
+		}
+	}
+
+	// Output parsing, success criteria and artifacts
+	if err := parseOutputParams(ctx, params); err != nil {
+		return nil, err
+	}
 
 	// Env (literal)
 	if envMap := getMap(params, "env"); envMap != nil && envMap.Fields != nil {
@@ -181,6 +290,19 @@ func BuildContext(
 		}
 	}
 
+	// tolerations, affinity, topology_spread_constraints
+	tolerations, affinity, spread, violations := parseScheduling(params, cfg.ScriptExecutor.Kubernetes.Tolerations)
+	if len(violations) > 0 {
+		return nil, &DetailedError{
+			Code:       CodeInvalidParameter,
+			Message:    fmt.Sprintf("invalid scheduling: %s: %s", violations[0].Field, violations[0].Description),
+			Violations: violations,
+		}
+	}
+	ctx.Tolerations = tolerations
+	ctx.Affinity = affinity
+	ctx.TopologySpreadConstraints = spread
+
 	// Resources
 	ctx.Resources = buildResources(params, cfg)
 
@@ -195,9 +317,14 @@ func BuildContext(
 		}
 	}
 
-	// labels, annotations
+	// labels, annotations; the keys the executor sets itself are reserved
+	var metaViolations []*executorv1.FieldViolation
 	if labels := getMap(params, "labels"); labels != nil && labels.Fields != nil {
 		for k, v := range labels.Fields {
+			if containsString(reservedLabels, k) {
+				metaViolations = append(metaViolations, &executorv1.FieldViolation{Field: "labels." + k, Description: "is reserved"})
+				continue
+			}
 			if v != nil {
 				ctx.Labels[k] = v.GetStringValue()
 			}
@@ -205,18 +332,137 @@ func BuildContext(
 	}
 	if ann := getMap(params, "annotations"); ann != nil && ann.Fields != nil {
 		for k, v := range ann.Fields {
+			if containsString(reservedAnnotations, k) {
+				metaViolations = append(metaViolations, &executorv1.FieldViolation{Field: "annotations." + k, Description: "is reserved"})
+				continue
+			}
 			if v != nil {
 				ctx.Annotations[k] = v.GetStringValue()
 			}
 		}
 	}
+	if len(metaViolations) > 0 {
+		sort.Slice(metaViolations, func(i, j int) bool { return metaViolations[i].Field < metaViolations[j].Field })
+		return nil, &DetailedError{
+			Code:       CodeInvalidParameter,
+			Message:    fmt.Sprintf("invalid %s: key is reserved", metaViolations[0].Field),
+			Violations: metaViolations,
+		}
+	}
 
 	// priority_class_name
 	ctx.PriorityClassName = getString(params, "priority_class_name", "")
 
+	// Job profile
+	profiles := cfg.ScriptExecutor.JobProfiles
+	ctx.Profile = getString(params, "profile", profiles.Default)
+	if _, ok := profiles.Profiles[ctx.Profile]; ctx.Profile != "" && !ok {
+		return nil, &DetailedError{
+			Code:    CodeInvalidParameter,
+			Message: fmt.Sprintf("unknown job profile %q", ctx.Profile),
+			Violations: []*executorv1.FieldViolation{
+				{Field: "profile", Description: "must be one of: " + strings.Join(sortedKeys(profiles.Profiles), ", ")},
+			},
+		}
+	}
+
+	// Persistent workspace
+	workspace, err := parseWorkspace(params, execCtx)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Workspace = workspace
+
+	// Result cache
+	if ctx.CacheTTL, err = parseCache(params, cfg); err != nil {
+		return nil, err
+	}
+	if ctx.CacheTTL > 0 && ctx.Workspace != nil {
+		// The workspace contents are not part of the cache key
+		return nil, &DetailedError{
+			Code:    CodeInvalidParameter,
+			Message: "cache cannot be combined with a persistent workspace",
+			Violations: []*executorv1.FieldViolation{
+				{Field: "cache", Description: "cannot be combined with workspace.persist"},
+			},
+		}
+	}
+
 	return ctx, nil
 }
 
+// parseOutputParams sets the parameters the result is checked against:
+// output_format, extract, success_criteria and artifacts.
+func parseOutputParams(ctx *Context, params *structpb.Struct) error {
+	ctx.OutputFormat = getString(params, "output_format", OutputFormatText)
+	switch ctx.OutputFormat {
+	case OutputFormatText, OutputFormatJSON, OutputFormatYAML, OutputFormatLines:
+	default:
+		return &DetailedError{
+			Code:    CodeInvalidParameter,
+			Message: fmt.Sprintf("invalid output_format %q", ctx.OutputFormat),
+			Violations: []*executorv1.FieldViolation{
+				{Field: "output_format", Description: "must be one of text, json, yaml, lines"},
+			},
+		}
+	}
+	if extract := getMap(params, "extract"); extract != nil && extract.Fields != nil {
+		ctx.Extract = make(map[string]string)
+		var violations []*executorv1.FieldViolation
+		for name, v := range extract.Fields {
+			expr := v.GetStringValue()
+			if _, err := compileExtractor(name, expr); err != nil || expr == "" {
+				desc := "must be a regex or a JSONPath starting with $"
+				if err != nil {
+					desc = err.Error()
+				}
+				violations = append(violations, &executorv1.FieldViolation{Field: "extract." + name, Description: desc})
+				continue
+			}
+			ctx.Extract[name] = expr
+		}
+		if len(violations) > 0 {
+			return &DetailedError{
+				Code:       CodeInvalidParameter,
+				Message:    "invalid extract expressions",
+				Violations: violations,
+			}
+		}
+	}
+
+	// Success criteria
+	criteria, violations := parseSuccessCriteria(params)
+	if len(violations) > 0 {
+		return &DetailedError{
+			Code:       CodeInvalidParameter,
+			Message:    "invalid success_criteria",
+			Violations: violations,
+		}
+	}
+	ctx.SuccessCriteria = criteria
+
+	// Artifacts
+	var artifactViolations []*executorv1.FieldViolation
+	for i, pattern := range getStringSlice(params, "artifacts") {
+		if desc := artifactPatternError(pattern); desc != "" {
+			artifactViolations = append(artifactViolations, &executorv1.FieldViolation{
+				Field:       fmt.Sprintf("artifacts[%d]", i),
+				Description: desc,
+			})
+			continue
+		}
+		ctx.Artifacts = append(ctx.Artifacts, pattern)
+	}
+	if len(artifactViolations) > 0 {
+		return &DetailedError{
+			Code:       CodeInvalidParameter,
+			Message:    "invalid artifacts",
+			Violations: artifactViolations,
+		}
+	}
+	return nil
+}
+
 func buildResources(params *structpb.Struct, cfg *config.Config) corev1.ResourceRequirements {
 	req := corev1.ResourceRequirements{
 		Requests: corev1.ResourceList{},
@@ -0,0 +1,301 @@
+package execution
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// SuccessCriteria decides whether a script that ran to completion succeeded.
+// Checks run in order: exit codes, stdout and stderr patterns, then output
+// assertions. The first failing check decides FAILED.
+type SuccessCriteria struct {
+	ExitCodes        []int
+	StdoutMatches    []*regexp.Regexp
+	StdoutNotMatches []*regexp.Regexp
+	StderrMatches    []*regexp.Regexp
+	StderrNotMatches []*regexp.Regexp
+	Outputs          []OutputAssertion
+}
+
+// OutputAssertion checks a value selected by a JSONPath from the derived
+// output fields (outputs, parsed, extracted).
+type OutputAssertion struct {
+	Path     string
+	Operator string
+	Value    interface{}
+
+	path  *jsonpath.JSONPath
+	match *regexp.Regexp
+}
+
+// Output assertion operators.
+var assertionOperators = []string{"equals", "not_equals", "matches", "exists", "greater_than", "less_than"}
+
+// verdict is the outcome of evaluating success criteria.
+type verdict struct {
+	Succeeded bool
+	Criterion string
+	Detail    string
+}
+
+// parseSuccessCriteria reads the success_criteria parameter. It returns nil if
+// the parameter is absent.
+func parseSuccessCriteria(params *structpb.Struct) (*SuccessCriteria, []*executorv1.FieldViolation) {
+	s := getMap(params, "success_criteria")
+	if s == nil {
+		return nil, nil
+	}
+	var violations []*executorv1.FieldViolation
+	violation := func(field, desc string) {
+		violations = append(violations, &executorv1.FieldViolation{Field: "success_criteria." + field, Description: desc})
+	}
+
+	c := &SuccessCriteria{}
+	for i, v := range getList(s, "exit_codes") {
+		n, ok := v.GetKind().(*structpb.Value_NumberValue)
+		if !ok || n.NumberValue != float64(int(n.NumberValue)) || n.NumberValue < 0 || n.NumberValue > 255 {
+			violation(fmt.Sprintf("exit_codes[%d]", i), "must be an integer between 0 and 255")
+			continue
+		}
+		c.ExitCodes = append(c.ExitCodes, int(n.NumberValue))
+	}
+	if len(c.ExitCodes) == 0 {
+		c.ExitCodes = []int{0}
+	}
+
+	patterns := func(key string) []*regexp.Regexp {
+		var out []*regexp.Regexp
+		for i, p := range getStringSlice(s, key) {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				violation(fmt.Sprintf("%s[%d]", key, i), fmt.Sprintf("invalid regex: %v", err))
+				continue
+			}
+			out = append(out, re)
+		}
+		return out
+	}
+	c.StdoutMatches = patterns("stdout_matches")
+	c.StdoutNotMatches = patterns("stdout_not_matches")
+	c.StderrMatches = patterns("stderr_matches")
+	c.StderrNotMatches = patterns("stderr_not_matches")
+
+	for i, v := range getList(s, "outputs") {
+		field := fmt.Sprintf("outputs[%d]", i)
+		a, err := parseOutputAssertion(v.GetStructValue())
+		if err != nil {
+			violation(field, err.Error())
+			continue
+		}
+		c.Outputs = append(c.Outputs, *a)
+	}
+	return c, violations
+}
+
+// parseOutputAssertion reads {path: "$.outputs.count", <operator>: value}.
+func parseOutputAssertion(s *structpb.Struct) (*OutputAssertion, error) {
+	if s == nil {
+		return nil, fmt.Errorf("must be an object with a path and one of %s", strings.Join(assertionOperators, ", "))
+	}
+	a := &OutputAssertion{Path: getString(s, "path", "")}
+	if !strings.HasPrefix(a.Path, "$") {
+		return nil, fmt.Errorf("path must be a JSONPath starting with $")
+	}
+	a.path = jsonpath.New(a.Path)
+	if err := a.path.Parse("{" + a.Path[1:] + "}"); err != nil {
+		return nil, fmt.Errorf("invalid JSONPath: %w", err)
+	}
+
+	for _, op := range assertionOperators {
+		v, ok := s.Fields[op]
+		if !ok {
+			continue
+		}
+		if a.Operator != "" {
+			return nil, fmt.Errorf("only one of %s may be set", strings.Join(assertionOperators, ", "))
+		}
+		a.Operator = op
+		a.Value = v.AsInterface()
+	}
+	switch a.Operator {
+	case "":
+		return nil, fmt.Errorf("one of %s is required", strings.Join(assertionOperators, ", "))
+	case "matches":
+		pattern, ok := a.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("matches must be a regex")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+		a.match = re
+	case "exists":
+		if _, ok := a.Value.(bool); !ok {
+			return nil, fmt.Errorf("exists must be true or false")
+		}
+	case "greater_than", "less_than":
+		if _, ok := a.Value.(float64); !ok {
+			return nil, fmt.Errorf("%s must be a number", a.Operator)
+		}
+	}
+	return a, nil
+}
+
+// acceptedFailureCodes returns the non-zero exit codes the criteria accept,
+// sorted and without duplicates.
+func (c *SuccessCriteria) acceptedFailureCodes() []int32 {
+	if c == nil {
+		return nil
+	}
+	seen := make(map[int]bool, len(c.ExitCodes))
+	var codes []int32
+	for _, code := range c.ExitCodes {
+		if code != 0 && !seen[code] {
+			seen[code] = true
+			codes = append(codes, int32(code))
+		}
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// evaluate applies the criteria to a completed script and its derived output.
+func (c *SuccessCriteria) evaluate(result *Result, derived map[string]interface{}) verdict {
+	allowed := false
+	for _, code := range c.ExitCodes {
+		if result.ExitCode == code {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return verdict{Criterion: "exit_codes", Detail: fmt.Sprintf("exit code %d is not one of %v", result.ExitCode, c.ExitCodes)}
+	}
+
+	checks := []struct {
+		name     string
+		text     string
+		patterns []*regexp.Regexp
+		want     bool
+	}{
+		{"stdout_matches", result.Stdout, c.StdoutMatches, true},
+		{"stdout_not_matches", result.Stdout, c.StdoutNotMatches, false},
+		{"stderr_matches", result.Stderr, c.StderrMatches, true},
+		{"stderr_not_matches", result.Stderr, c.StderrNotMatches, false},
+	}
+	for _, check := range checks {
+		for _, re := range check.patterns {
+			if re.MatchString(check.text) == check.want {
+				continue
+			}
+			if check.want {
+				return verdict{Criterion: check.name, Detail: fmt.Sprintf("%q did not match", re.String())}
+			}
+			return verdict{Criterion: check.name, Detail: fmt.Sprintf("%q matched", re.String())}
+		}
+	}
+
+	for _, a := range c.Outputs {
+		if detail := a.check(derived); detail != "" {
+			return verdict{Criterion: "outputs", Detail: fmt.Sprintf("%s: %s", a.Path, detail)}
+		}
+	}
+
+	if len(c.StdoutMatches)+len(c.StdoutNotMatches)+len(c.StderrMatches)+len(c.StderrNotMatches)+len(c.Outputs) == 0 {
+		return verdict{Succeeded: true, Criterion: "exit_codes", Detail: fmt.Sprintf("exit code %d is allowed", result.ExitCode)}
+	}
+	return verdict{Succeeded: true, Criterion: "all", Detail: "all success criteria met"}
+}
+
+// check returns why the assertion fails, or "" if it holds.
+func (a *OutputAssertion) check(derived map[string]interface{}) string {
+	var values []interface{}
+	if results, err := a.path.FindResults(derived); err == nil {
+		for _, set := range results {
+			for _, v := range set {
+				values = append(values, v.Interface())
+			}
+		}
+	}
+	var actual interface{}
+	switch len(values) {
+	case 0:
+		if a.Operator == "exists" && a.Value == false {
+			return ""
+		}
+		if a.Operator == "exists" {
+			return "does not exist"
+		}
+		return "no value"
+	case 1:
+		actual = values[0]
+	default:
+		actual = values
+	}
+
+	switch a.Operator {
+	case "exists":
+		if a.Value == false {
+			return "exists"
+		}
+	case "equals":
+		if !reflect.DeepEqual(actual, a.Value) {
+			return fmt.Sprintf("%v does not equal %v", actual, a.Value)
+		}
+	case "not_equals":
+		if reflect.DeepEqual(actual, a.Value) {
+			return fmt.Sprintf("equals %v", a.Value)
+		}
+	case "matches":
+		s, ok := actual.(string)
+		if !ok {
+			s = fmt.Sprint(actual)
+		}
+		if !a.match.MatchString(s) {
+			return fmt.Sprintf("%q does not match %q", s, a.match.String())
+		}
+	case "greater_than", "less_than":
+		n, ok := actual.(float64)
+		if !ok {
+			return fmt.Sprintf("%v is not a number", actual)
+		}
+		limit := a.Value.(float64)
+		if a.Operator == "greater_than" && !(n > limit) {
+			return fmt.Sprintf("%v is not greater than %v", n, limit)
+		}
+		if a.Operator == "less_than" && !(n < limit) {
+			return fmt.Sprintf("%v is not less than %v", n, limit)
+		}
+	}
+	return ""
+}
+
+// applyVerdict sets the response status from a verdict and reports the
+// deciding criterion in ErrorDetails.metadata.
+func applyVerdict(resp *executorv1.ExecuteResponse, v verdict) {
+	details := &executorv1.ErrorDetails{
+		Code:    CodeCriteriaMet,
+		Message: v.Detail,
+		Metadata: map[string]string{
+			"decided_by": v.Criterion,
+			"detail":     v.Detail,
+		},
+	}
+	if v.Succeeded {
+		resp.Status = executorv1.ExecuteResponse_STATUS_SUCCEEDED
+		resp.Error = ""
+	} else {
+		resp.Status = executorv1.ExecuteResponse_STATUS_FAILED
+		resp.Error = fmt.Sprintf("success criteria not met: %s: %s", v.Criterion, v.Detail)
+		details.Code = CodeCriteriaNotMet
+	}
+	resp.ErrorDetails = details
+}
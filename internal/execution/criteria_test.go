@@ -0,0 +1,181 @@
+package execution
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSuccessCriteria(t *testing.T) {
+	tests := []struct {
+		name          string
+		criteria      interface{}
+		wantExitCodes []int
+		wantFields    []string
+	}{
+		{name: "exit codes default to 0", criteria: map[string]interface{}{}, wantExitCodes: []int{0}},
+		{name: "exit codes", criteria: map[string]interface{}{"exit_codes": []interface{}{0, 3}}, wantExitCodes: []int{0, 3}},
+		{
+			name:          "invalid exit codes",
+			criteria:      map[string]interface{}{"exit_codes": []interface{}{1.5, 256, -1, "2", 4}},
+			wantExitCodes: []int{4},
+			wantFields:    []string{"success_criteria.exit_codes[0]", "success_criteria.exit_codes[1]", "success_criteria.exit_codes[2]", "success_criteria.exit_codes[3]"},
+		},
+		{
+			name:          "invalid pattern",
+			criteria:      map[string]interface{}{"stdout_matches": []interface{}{"ok", "("}, "stderr_not_matches": []interface{}{"["}},
+			wantExitCodes: []int{0},
+			wantFields:    []string{"success_criteria.stdout_matches[1]", "success_criteria.stderr_not_matches[0]"},
+		},
+		{
+			name: "output assertions",
+			criteria: map[string]interface{}{"outputs": []interface{}{
+				map[string]interface{}{"path": "$.outputs.count", "greater_than": 1},
+				map[string]interface{}{"path": "outputs.count", "equals": 1},
+				map[string]interface{}{"path": "$.outputs.count"},
+				map[string]interface{}{"path": "$.outputs.count", "equals": 1, "less_than": 2},
+				map[string]interface{}{"path": "$.outputs.name", "matches": 3},
+				map[string]interface{}{"path": "$.outputs.name", "matches": "("},
+				map[string]interface{}{"path": "$.outputs.name", "exists": "yes"},
+				map[string]interface{}{"path": "$.outputs.count", "less_than": "2"},
+				map[string]interface{}{"path": "$.outputs[", "exists": true},
+				"not an object",
+			}},
+			wantExitCodes: []int{0},
+			wantFields: []string{
+				"success_criteria.outputs[1]", "success_criteria.outputs[2]", "success_criteria.outputs[3]",
+				"success_criteria.outputs[4]", "success_criteria.outputs[5]", "success_criteria.outputs[6]",
+				"success_criteria.outputs[7]", "success_criteria.outputs[8]", "success_criteria.outputs[9]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, violations := parseSuccessCriteria(mustStruct(t, map[string]interface{}{"success_criteria": tt.criteria}))
+			if c == nil {
+				t.Fatal("parseSuccessCriteria() = nil")
+			}
+			if !reflect.DeepEqual(c.ExitCodes, tt.wantExitCodes) {
+				t.Errorf("ExitCodes = %v, want %v", c.ExitCodes, tt.wantExitCodes)
+			}
+			var fields []string
+			for _, v := range violations {
+				fields = append(fields, v.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("violations on %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+
+	if c, violations := parseSuccessCriteria(mustStruct(t, map[string]interface{}{})); c != nil || violations != nil {
+		t.Errorf("parseSuccessCriteria() without the parameter = %v, %v, want nil", c, violations)
+	}
+}
+
+func TestEvaluateSuccessCriteria(t *testing.T) {
+	derived := map[string]interface{}{
+		"outputs":   map[string]interface{}{"count": float64(3), "name": "web-1", "ready": true},
+		"parsed":    map[string]interface{}{"items": []interface{}{map[string]interface{}{"id": "a"}, map[string]interface{}{"id": "b"}}},
+		"extracted": map[string]interface{}{"version": "1.2.3"},
+	}
+	tests := []struct {
+		name          string
+		criteria      map[string]interface{}
+		result        *Result
+		wantSucceeded bool
+		wantCriterion string
+	}{
+		{name: "exit code allowed", criteria: map[string]interface{}{}, result: &Result{}, wantSucceeded: true, wantCriterion: "exit_codes"},
+		{name: "exit code not allowed", criteria: map[string]interface{}{}, result: &Result{ExitCode: 1}, wantCriterion: "exit_codes"},
+		{name: "accepted non-zero exit code", criteria: map[string]interface{}{"exit_codes": []interface{}{0, 2}}, result: &Result{ExitCode: 2}, wantSucceeded: true, wantCriterion: "exit_codes"},
+		{
+			name:          "all met",
+			criteria:      map[string]interface{}{"stdout_matches": []interface{}{"^ok"}, "stderr_not_matches": []interface{}{"ERROR"}},
+			result:        &Result{Stdout: "ok\n", Stderr: "warning\n"},
+			wantSucceeded: true,
+			wantCriterion: "all",
+		},
+		{name: "stdout does not match", criteria: map[string]interface{}{"stdout_matches": []interface{}{"^ok"}}, result: &Result{Stdout: "fail\n"}, wantCriterion: "stdout_matches"},
+		{name: "stdout matches a forbidden pattern", criteria: map[string]interface{}{"stdout_not_matches": []interface{}{"fail"}}, result: &Result{Stdout: "fail\n"}, wantCriterion: "stdout_not_matches"},
+		{name: "stderr does not match", criteria: map[string]interface{}{"stderr_matches": []interface{}{"done"}}, result: &Result{}, wantCriterion: "stderr_matches"},
+		{name: "stderr matches a forbidden pattern", criteria: map[string]interface{}{"stderr_not_matches": []interface{}{"ERROR"}}, result: &Result{Stderr: "ERROR x"}, wantCriterion: "stderr_not_matches"},
+		{
+			name:          "exit code is checked first",
+			criteria:      map[string]interface{}{"stdout_matches": []interface{}{"^ok"}},
+			result:        &Result{ExitCode: 1},
+			wantCriterion: "exit_codes",
+		},
+		{
+			name:          "output assertion fails",
+			criteria:      map[string]interface{}{"outputs": []interface{}{map[string]interface{}{"path": "$.outputs.count", "greater_than": 5}}},
+			result:        &Result{},
+			wantCriterion: "outputs",
+		},
+		{
+			name: "output assertions hold",
+			criteria: map[string]interface{}{"outputs": []interface{}{
+				map[string]interface{}{"path": "$.outputs.count", "equals": 3},
+				map[string]interface{}{"path": "$.extracted.version", "matches": `^1\.`},
+			}},
+			result:        &Result{},
+			wantSucceeded: true,
+			wantCriterion: "all",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, violations := parseSuccessCriteria(mustStruct(t, map[string]interface{}{"success_criteria": tt.criteria}))
+			if len(violations) > 0 {
+				t.Fatalf("parseSuccessCriteria() violations = %v", violations)
+			}
+			v := c.evaluate(tt.result, derived)
+			if v.Succeeded != tt.wantSucceeded || v.Criterion != tt.wantCriterion {
+				t.Errorf("evaluate() = %+v, want succeeded %v decided by %s", v, tt.wantSucceeded, tt.wantCriterion)
+			}
+		})
+	}
+}
+
+func TestOutputAssertionCheck(t *testing.T) {
+	derived := map[string]interface{}{
+		"outputs": map[string]interface{}{"count": float64(3), "name": "web-1", "ready": true, "ratio": 0.5},
+		"parsed":  map[string]interface{}{"items": []interface{}{map[string]interface{}{"id": "a"}, map[string]interface{}{"id": "b"}}},
+	}
+	tests := []struct {
+		name      string
+		assertion map[string]interface{}
+		wantHolds bool
+	}{
+		{name: "exists", assertion: map[string]interface{}{"path": "$.outputs.name", "exists": true}, wantHolds: true},
+		{name: "exists on a missing value", assertion: map[string]interface{}{"path": "$.outputs.missing", "exists": true}},
+		{name: "exists false on a missing value", assertion: map[string]interface{}{"path": "$.outputs.missing", "exists": false}, wantHolds: true},
+		{name: "exists false on a present value", assertion: map[string]interface{}{"path": "$.outputs.name", "exists": false}},
+		{name: "equals a number", assertion: map[string]interface{}{"path": "$.outputs.count", "equals": 3}, wantHolds: true},
+		{name: "equals a string", assertion: map[string]interface{}{"path": "$.outputs.name", "equals": "web-2"}},
+		{name: "equals a bool", assertion: map[string]interface{}{"path": "$.outputs.ready", "equals": true}, wantHolds: true},
+		{name: "equals a multi-match list", assertion: map[string]interface{}{"path": "$.parsed.items[*].id", "equals": []interface{}{"a", "b"}}, wantHolds: true},
+		{name: "equals on a missing value", assertion: map[string]interface{}{"path": "$.outputs.missing", "equals": nil}},
+		{name: "not_equals", assertion: map[string]interface{}{"path": "$.outputs.name", "not_equals": "web-2"}, wantHolds: true},
+		{name: "not_equals the value", assertion: map[string]interface{}{"path": "$.outputs.name", "not_equals": "web-1"}},
+		{name: "matches", assertion: map[string]interface{}{"path": "$.outputs.name", "matches": `^web-\d+$`}, wantHolds: true},
+		{name: "matches a number as text", assertion: map[string]interface{}{"path": "$.outputs.count", "matches": `^3$`}, wantHolds: true},
+		{name: "does not match", assertion: map[string]interface{}{"path": "$.outputs.name", "matches": `^db-`}},
+		{name: "greater_than", assertion: map[string]interface{}{"path": "$.outputs.count", "greater_than": 2}, wantHolds: true},
+		{name: "greater_than an equal value", assertion: map[string]interface{}{"path": "$.outputs.count", "greater_than": 3}},
+		{name: "less_than", assertion: map[string]interface{}{"path": "$.outputs.ratio", "less_than": 0.75}, wantHolds: true},
+		{name: "less_than a smaller value", assertion: map[string]interface{}{"path": "$.outputs.ratio", "less_than": 0.25}},
+		{name: "numeric comparison on a string", assertion: map[string]interface{}{"path": "$.outputs.name", "greater_than": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := parseOutputAssertion(mustStruct(t, tt.assertion))
+			if err != nil {
+				t.Fatal(err)
+			}
+			detail := a.check(derived)
+			if (detail == "") != tt.wantHolds {
+				t.Errorf("check() = %q, want holds %v", detail, tt.wantHolds)
+			}
+		})
+	}
+}
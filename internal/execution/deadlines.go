@@ -0,0 +1,81 @@
+package execution
+
+import (
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Deadlines bounds how long a Job may spend in each phase. Zero values are
+// unbounded.
+type Deadlines struct {
+	// Scheduling runs from Job creation until the pod is scheduled.
+	Scheduling time.Duration
+	// ImagePull runs from scheduling until the script container starts.
+	ImagePull time.Duration
+	// Runtime runs from the start of the script container until it exits.
+	Runtime time.Duration
+	// Total bounds the whole wait, including result collection.
+	Total time.Duration
+}
+
+// deadlinesFor returns the deadlines of an execution.
+func deadlinesFor(ctx *Context) Deadlines {
+	return Deadlines{
+		Scheduling: ctx.SchedulingTimeout,
+		ImagePull:  ctx.ImagePullTimeout,
+		Runtime:    ctx.Timeout,
+		// Leave the Job's own deadline room to fire first.
+		Total: jobDeadline(ctx) + 30*time.Second,
+	}
+}
+
+// jobDeadline returns how long the Job may run: the sum of its phase
+// deadlines, capped by MaxTimeout.
+func jobDeadline(ctx *Context) time.Duration {
+	d := ctx.SchedulingTimeout + ctx.ImagePullTimeout + ctx.Timeout
+	if ctx.MaxTimeout > 0 && d > ctx.MaxTimeout {
+		d = ctx.MaxTimeout
+	}
+	return d
+}
+
+// overdue returns a timeout Failure when the pod has spent longer than its
+// deadline in the current phase.
+func (t *podTracker) overdue(d Deadlines, now time.Time) *Failure {
+	switch {
+	case !t.reached(PhasePodScheduled):
+		if d.Scheduling > 0 && now.Sub(t.reachedAt[PhaseJobCreated]) > d.Scheduling {
+			return timeoutFailure(CodeSchedulingTimeout, "pod was not scheduled within %v", d.Scheduling)
+		}
+	case !t.reached(PhaseRunning):
+		if d.ImagePull > 0 && now.Sub(t.reachedAt[PhasePodScheduled]) > d.ImagePull {
+			return timeoutFailure(CodeImagePullTimeout, "script container did not start within %v of scheduling", d.ImagePull)
+		}
+	case !t.reached(PhaseCompleting):
+		if d.Runtime > 0 && now.Sub(t.reachedAt[PhaseRunning]) > d.Runtime {
+			return timeoutFailure(CodeScriptTimeout, "script did not finish within %v", d.Runtime)
+		}
+	}
+	return nil
+}
+
+// jobDeadlineExceeded reports whether the Job was stopped by ActiveDeadlineSeconds.
+func jobDeadlineExceeded(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue && c.Reason == batchv1.JobReasonDeadlineExceeded {
+			return true
+		}
+	}
+	return false
+}
+
+func timeoutFailure(code, format string, args ...interface{}) *Failure {
+	return &Failure{
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Metadata: map[string]string{},
+	}
+}
@@ -0,0 +1,60 @@
+package execution
+
+import (
+	"testing"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/script"
+)
+
+func TestBuildContextTimeouts(t *testing.T) {
+	cfg := testConfig(t)
+	max := cfg.MaxTimeout()
+	tests := []struct {
+		name        string
+		params      map[string]interface{}
+		reqTimeout  time.Duration
+		wantTimeout time.Duration
+		wantJob     time.Duration
+		wantField   string
+	}{
+		{name: "parameter", params: map[string]interface{}{"timeout": "5m", "scheduling_timeout": "1m", "image_pull_timeout": "1m"}, wantTimeout: 5 * time.Minute, wantJob: 7 * time.Minute},
+		{name: "request timeout wins", params: map[string]interface{}{"timeout": "5m"}, reqTimeout: 10 * time.Minute, wantTimeout: 10 * time.Minute},
+		{name: "request timeout over the cap", reqTimeout: max + time.Second, wantField: "timeout"},
+		{name: "parameter over the cap", params: map[string]interface{}{"scheduling_timeout": (max + time.Second).String()}, wantField: "scheduling_timeout"},
+		{
+			name:        "job deadline capped",
+			params:      map[string]interface{}{"timeout": max.String(), "scheduling_timeout": max.String(), "image_pull_timeout": max.String()},
+			wantTimeout: max,
+			wantJob:     max,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := BuildContext(mustStruct(t, tt.params), &executorv1.ExecutionContext{ExecutionId: "exec-1"}, tt.reqTimeout,
+				"echo hi", &script.Source{Type: script.SourceInline}, "abc", "busybox:1.36", "", "", cfg)
+			if tt.wantField != "" {
+				de, ok := err.(*DetailedError)
+				if !ok || len(de.Violations) != 1 || de.Violations[0].Field != tt.wantField {
+					t.Fatalf("BuildContext() error = %v, want a violation of %s", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ctx.Timeout != tt.wantTimeout {
+				t.Errorf("Timeout = %v, want %v", ctx.Timeout, tt.wantTimeout)
+			}
+			if tt.wantJob != 0 {
+				if got := jobDeadline(ctx); got != tt.wantJob {
+					t.Errorf("jobDeadline() = %v, want %v", got, tt.wantJob)
+				}
+				if got := deadlinesFor(ctx).Total; got != tt.wantJob+30*time.Second {
+					t.Errorf("Total = %v, want %v", got, tt.wantJob+30*time.Second)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// maxDiagnosisEvents is the number of recent warning events attached to a Failure.
+const maxDiagnosisEvents = 5
+
+// Failure describes why a pod could not run the script to completion for
+// reasons other than the script itself.
+type Failure struct {
+	Code     string
+	Message  string
+	Metadata map[string]string
+}
+
+// imagePullFailures are waiting reasons after which the kubelet only backs off.
+// ErrImagePull alone is left out: the first pull attempt may fail transiently.
+var imagePullFailures = map[string]bool{
+	"ImagePullBackOff":  true,
+	"InvalidImageName":  true,
+	"ErrImageNeverPull": true,
+}
+
+// configFailures are waiting reasons caused by the pod spec (e.g. a missing
+// Secret key) that will not resolve on their own in time.
+var configFailures = map[string]bool{
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// stuckFailure returns a Failure when the pod is stuck in a state it will not
+// leave on its own, so the execution can end without waiting for the timeout.
+func (m *Monitor) stuckFailure(ctx context.Context, pod *corev1.Pod) *Failure {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		w := cs.State.Waiting
+		if w == nil {
+			continue
+		}
+		var code string
+		switch {
+		case imagePullFailures[w.Reason]:
+			code = CodeImagePullFailed
+		case configFailures[w.Reason]:
+			code = CodeContainerConfigError
+		default:
+			continue
+		}
+		f := &Failure{
+			Code:    code,
+			Message: fmt.Sprintf("container %s: %s: %s", cs.Name, w.Reason, w.Message),
+			Metadata: map[string]string{
+				"pod_name":  pod.Name,
+				"container": cs.Name,
+				"reason":    w.Reason,
+				"image":     cs.Image,
+			},
+		}
+		m.attachEvents(ctx, f, "Pod", pod.Name)
+		return f
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type != corev1.PodScheduled || c.Status != corev1.ConditionFalse || c.Reason != corev1.PodReasonUnschedulable {
+			continue
+		}
+		events := m.warningEvents(ctx, "Pod", pod.Name)
+		// The cluster autoscaler is adding capacity; give it a chance.
+		for _, e := range events {
+			if e.Reason == "TriggeredScaleUp" {
+				return nil
+			}
+		}
+		f := &Failure{
+			Code:    CodeUnschedulable,
+			Message: fmt.Sprintf("pod %s is unschedulable: %s", pod.Name, c.Message),
+			Metadata: map[string]string{
+				"pod_name": pod.Name,
+				"reason":   c.Reason,
+			},
+		}
+		setEvents(f, events)
+		return f
+	}
+	return nil
+}
+
+// terminalFailure returns a Failure when a finished pod was killed by the
+// node rather than exiting on its own.
+func (m *Monitor) terminalFailure(ctx context.Context, pod *corev1.Pod) *Failure {
+	if pod.Status.Reason == "Evicted" {
+		f := &Failure{
+			Code:    CodeEvicted,
+			Message: fmt.Sprintf("pod %s was evicted: %s", pod.Name, pod.Status.Message),
+			Metadata: map[string]string{
+				"pod_name": pod.Name,
+				"reason":   pod.Status.Reason,
+				"node":     pod.Spec.NodeName,
+			},
+		}
+		m.attachEvents(ctx, f, "Pod", pod.Name)
+		return f
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		t := cs.State.Terminated
+		if t == nil || t.Reason != "OOMKilled" {
+			continue
+		}
+		f := &Failure{
+			Code:    CodeOOMKilled,
+			Message: fmt.Sprintf("container %s was killed for exceeding its memory limit", cs.Name),
+			Metadata: map[string]string{
+				"pod_name":  pod.Name,
+				"container": cs.Name,
+				"reason":    t.Reason,
+				"exit_code": fmt.Sprintf("%d", t.ExitCode),
+			},
+		}
+		for _, c := range pod.Spec.Containers {
+			if c.Name == cs.Name {
+				if lim, ok := c.Resources.Limits[corev1.ResourceMemory]; ok {
+					f.Metadata["memory_limit"] = lim.String()
+				}
+			}
+		}
+		m.attachEvents(ctx, f, "Pod", pod.Name)
+		return f
+	}
+
+	// A failed init step keeps the script from ever starting
+	for _, cs := range pod.Status.InitContainerStatuses {
+		t := cs.State.Terminated
+		if t == nil || t.ExitCode == 0 || isNativeSidecar(pod, cs.Name) {
+			continue
+		}
+		f := &Failure{
+			Code:    CodeInitFailed,
+			Message: fmt.Sprintf("init container %s exited with code %d", cs.Name, t.ExitCode),
+			Metadata: map[string]string{
+				"pod_name":  pod.Name,
+				"container": cs.Name,
+				"reason":    t.Reason,
+				"exit_code": fmt.Sprintf("%d", t.ExitCode),
+			},
+		}
+		if tail := m.logTail(ctx, pod.Name, cs.Name); tail != "" {
+			f.Metadata["log_tail"] = tail
+		}
+		m.attachEvents(ctx, f, "Pod", pod.Name)
+		return f
+	}
+	return nil
+}
+
+// isNativeSidecar reports whether the named init container of a pod is a
+// sidecar (restartPolicy Always) rather than a run-to-completion step.
+func isNativeSidecar(pod *corev1.Pod, name string) bool {
+	for _, c := range pod.Spec.InitContainers {
+		if c.Name == name {
+			return c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways
+		}
+	}
+	return false
+}
+
+// logTail returns the last lines of a container's log, or "".
+func (m *Monitor) logTail(ctx context.Context, podName, container string) string {
+	data, err := m.client.CoreV1().Pods(m.namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container:  container,
+		TailLines:  ptr.To(int64(20)),
+		LimitBytes: ptr.To(int64(4096)),
+	}).DoRaw(ctx)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// jobFailure returns a Failure when the Job controller cannot create the pod
+// at all (quota, admission policy, missing service account).
+func (m *Monitor) jobFailure(ctx context.Context, job *batchv1.Job) *Failure {
+	for _, e := range m.warningEvents(ctx, "Job", job.Name) {
+		if e.Reason != "FailedCreate" {
+			continue
+		}
+		return &Failure{
+			Code:    CodePodCreateFailed,
+			Message: fmt.Sprintf("job %s cannot create its pod: %s", job.Name, e.Message),
+			Metadata: map[string]string{
+				"job_name": job.Name,
+				"reason":   e.Reason,
+				"events":   formatEvents([]corev1.Event{e}),
+			},
+		}
+	}
+	return nil
+}
+
+// warningEvents returns the Warning events of an object, newest first.
+func (m *Monitor) warningEvents(ctx context.Context, kind, name string) []corev1.Event {
+	list, err := m.client.CoreV1().Events(m.namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.kind=%s,involvedObject.name=%s,type=%s", kind, name, corev1.EventTypeWarning),
+	})
+	if err != nil {
+		return nil
+	}
+	events := list.Items
+	sort.Slice(events, func(i, j int) bool {
+		return eventTime(events[i]).After(eventTime(events[j]).Time)
+	})
+	return events
+}
+
+func (m *Monitor) attachEvents(ctx context.Context, f *Failure, kind, name string) {
+	setEvents(f, m.warningEvents(ctx, kind, name))
+}
+
+func setEvents(f *Failure, events []corev1.Event) {
+	if len(events) == 0 {
+		return
+	}
+	if len(events) > maxDiagnosisEvents {
+		events = events[:maxDiagnosisEvents]
+	}
+	f.Metadata["events"] = formatEvents(events)
+}
+
+func formatEvents(events []corev1.Event) string {
+	lines := make([]string, 0, len(events))
+	for _, e := range events {
+		lines = append(lines, fmt.Sprintf("%s: %s", e.Reason, e.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func eventTime(e corev1.Event) metav1.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp
+	}
+	if !e.EventTime.IsZero() {
+		return metav1.Time{Time: e.EventTime.Time}
+	}
+	return e.CreationTimestamp
+}
@@ -0,0 +1,59 @@
+package execution
+
+import (
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+)
+
+// Error codes reported in ErrorDetails.code.
+const (
+	CodeCancelled           = "CANCELLED"
+	CodeExecutionConflict   = "EXECUTION_ID_CONFLICT"
+	CodeInvalidParameter    = "INVALID_PARAMETER"
+	CodeInvalidOutputs      = "INVALID_OUTPUTS"
+	CodeOutputParseFailed   = "OUTPUT_PARSE_FAILED"
+	CodeOutputTruncated     = "OUTPUT_TRUNCATED"
+	CodeArtifactsFailed     = "ARTIFACT_COLLECTION_FAILED"
+	CodeUnsupportedLanguage = "UNSUPPORTED_LANGUAGE"
+	CodeTemplateFailed      = "TEMPLATE_RENDER_FAILED"
+	CodeResultLost          = "RESULT_LOST"
+
+	// Success criteria verdicts.
+	CodeCriteriaMet    = "SUCCESS_CRITERIA_MET"
+	CodeCriteriaNotMet = "SUCCESS_CRITERIA_NOT_MET"
+
+	// Pod failures diagnosed by the monitor.
+	CodeImagePullFailed      = "IMAGE_PULL_FAILED"
+	CodeUnschedulable        = "UNSCHEDULABLE"
+	CodeOOMKilled            = "OOM_KILLED"
+	CodeEvicted              = "EVICTED"
+	CodeContainerConfigError = "CONTAINER_CONFIG_ERROR"
+	CodePodCreateFailed      = "POD_CREATE_FAILED"
+	CodeInitFailed           = "INIT_CONTAINER_FAILED"
+
+	// Deadlines (reported with STATUS_TIMEOUT).
+	CodeSchedulingTimeout = "SCHEDULING_TIMEOUT"
+	CodeImagePullTimeout  = "IMAGE_PULL_TIMEOUT"
+	CodeScriptTimeout     = "SCRIPT_TIMEOUT"
+	CodeDeadlineExceeded  = "DEADLINE_EXCEEDED"
+)
+
+// DetailedError is an error that carries structured ErrorDetails for the response.
+type DetailedError struct {
+	Code       string
+	Message    string
+	Metadata   map[string]string
+	Violations []*executorv1.FieldViolation
+}
+
+func (e *DetailedError) Error() string {
+	return e.Message
+}
+
+func (e *DetailedError) details() *executorv1.ErrorDetails {
+	return &executorv1.ErrorDetails{
+		Code:            e.Code,
+		Message:         e.Message,
+		Metadata:        e.Metadata,
+		FieldViolations: e.Violations,
+	}
+}
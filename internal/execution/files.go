@@ -0,0 +1,136 @@
+package execution
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/audit"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// defaultMaxFilesSize applies when security.max_files_size is unset. Files
+// share the input Secret with the script and stdin, which must stay under the
+// 1MB object limit.
+const defaultMaxFilesSize = 262144
+
+// InputFile is a file materialized into /workspace before the script starts.
+type InputFile struct {
+	// Path is relative to /workspace.
+	Path    string
+	Content []byte
+	// Sensitive files are readable only by the script user and are removed
+	// before artifacts are collected.
+	Sensitive  bool
+	Executable bool
+}
+
+// workspacePathError returns why p is not a usable path relative to
+// /workspace, or "". Paths must not contain whitespace, since the run wrapper
+// passes them in whitespace-separated lists.
+func workspacePathError(p string) string {
+	switch {
+	case p == "":
+		return "must not be empty"
+	case strings.HasPrefix(p, "/"):
+		return "must be relative to /workspace"
+	case strings.ContainsAny(p, " \t\r\n"):
+		return "must not contain whitespace"
+	}
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return "must not contain .."
+		}
+	}
+	return ""
+}
+
+// parseFiles reads the files parameter: a map from path to content, either a
+// string or {content, encoding: "text"|"base64", sensitive}.
+func parseFiles(params *structpb.Struct, maxSize int) ([]InputFile, []*executorv1.FieldViolation) {
+	m := getMap(params, "files")
+	if m == nil || len(m.Fields) == 0 {
+		return nil, nil
+	}
+	if maxSize <= 0 {
+		maxSize = defaultMaxFilesSize
+	}
+
+	paths := make([]string, 0, len(m.Fields))
+	for p := range m.Fields {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var files []InputFile
+	var violations []*executorv1.FieldViolation
+	violation := func(p, desc string) {
+		violations = append(violations, &executorv1.FieldViolation{Field: "files." + p, Description: desc})
+	}
+	total := 0
+	for _, p := range paths {
+		if desc := workspacePathError(p); desc != "" {
+			violation(p, desc)
+			continue
+		}
+		if path.Clean(p) != p {
+			violation(p, "must be a clean path to a file")
+			continue
+		}
+		if strings.HasPrefix(p, ".ocr") {
+			violation(p, "names starting with .ocr are reserved")
+			continue
+		}
+		f := InputFile{Path: p}
+		v := m.Fields[p]
+		switch k := v.GetKind().(type) {
+		case *structpb.Value_StringValue:
+			f.Content = []byte(k.StringValue)
+		case *structpb.Value_StructValue:
+			content := getString(k.StructValue, "content", "")
+			switch encoding := getString(k.StructValue, "encoding", "text"); encoding {
+			case "text":
+				f.Content = []byte(content)
+			case "base64":
+				data, err := base64.StdEncoding.DecodeString(content)
+				if err != nil {
+					violation(p, fmt.Sprintf("invalid base64: %v", err))
+					continue
+				}
+				f.Content = data
+			default:
+				violation(p, fmt.Sprintf("unknown encoding %q (text, base64)", encoding))
+				continue
+			}
+			f.Sensitive = getBool(k.StructValue, "sensitive")
+		default:
+			violation(p, "must be a string or an object with content")
+			continue
+		}
+		total += len(f.Content)
+		files = append(files, f)
+	}
+	if total > maxSize {
+		violations = append(violations, &executorv1.FieldViolation{
+			Field:       "files",
+			Description: fmt.Sprintf("total size %d bytes exceeds %d bytes", total, maxSize),
+		})
+	}
+	return files, violations
+}
+
+// inputFileKey returns the input Secret key holding the i-th input file.
+func inputFileKey(i int) string {
+	return fmt.Sprintf("file-%d", i)
+}
+
+func auditFiles(files []InputFile) []audit.File {
+	var out []audit.File
+	for _, f := range files {
+		out = append(out, audit.File{Path: f.Path, Content: f.Content, Sensitive: f.Sensitive})
+	}
+	return out
+}
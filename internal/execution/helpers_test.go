@@ -0,0 +1,29 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/rakeshavasarala/script-executor/internal/config"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// testConfig returns the default configuration.
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+	t.Setenv("CONFIG_PATH", "")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// mustStruct returns m as request parameters.
+func mustStruct(t *testing.T, m map[string]interface{}) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
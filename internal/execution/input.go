@@ -0,0 +1,196 @@
+package execution
+
+import (
+	"fmt"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/script"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// inputVolumeName is the volume holding the per-execution input Secret.
+	inputVolumeName = "input"
+	// inputMountPath is where the input Secret is mounted in the script container.
+	inputMountPath = "/ocr/input"
+	// inputStdinKey is the input Secret key holding stdin content.
+	inputStdinKey = "stdin"
+	// inputScriptKey is the input Secret key holding the script.
+	inputScriptKey = "script"
+	// inputFilesDir is the directory of the input volume holding the input
+	// files, laid out as they are copied into /workspace.
+	inputFilesDir = "files"
+
+	// scriptVolumeName is the volume exposing the script from the input Secret.
+	scriptVolumeName = "script"
+	// scriptMountPath is where the script volume is mounted; the script is
+	// the file scriptFile in it.
+	scriptMountPath = "/scripts"
+	scriptFile      = "run"
+)
+
+// scriptPath returns the path of the mounted script, named with the
+// language's extension for interpreters that care (pwsh only runs .ps1).
+func scriptPath(ctx *Context) string {
+	return scriptMountPath + "/" + scriptFile + ctx.ScriptExtension
+}
+
+// inputSecretName returns the name of the per-execution input Secret of a Job.
+func inputSecretName(jobName string) string {
+	return jobName + "-input"
+}
+
+// hasInput reports whether the execution needs an input Secret.
+func hasInput(ctx *Context) bool {
+	return ctx.Stdin != "" || len(ctx.Files) > 0 || ctx.Inputs != nil || scriptFromInput(ctx)
+}
+
+// mountsInput reports whether the input volume is mounted at inputMountPath.
+func mountsInput(ctx *Context) bool {
+	return ctx.Stdin != "" || len(ctx.Files) > 0 || ctx.Inputs != nil
+}
+
+// scriptFromInput reports whether the script is delivered in the input Secret.
+// script_path scripts are already files in the approved-scripts ConfigMap, and
+// bundles are unpacked into /workspace as input files; every other source is
+// written to the Secret, so the script never appears in the Job spec or the
+// process arguments.
+func scriptFromInput(ctx *Context) bool {
+	if ctx.ScriptSource == nil {
+		return true
+	}
+	return ctx.ScriptSource.Type != script.SourcePath && ctx.ScriptSource.Bundle == nil
+}
+
+// maxInputSecretSize bounds the data of the input Secret, leaving room for its
+// metadata below the 1MiB limit of a Kubernetes object.
+const maxInputSecretSize = 1000 * 1024
+
+// checkInputSecretSize checks that the script, stdin, input files (bundle
+// files included) and inputs.json fit in one input Secret together; each is
+// also limited on its own, but not so their sum fits.
+func checkInputSecretSize(ctx *Context) error {
+	var parts []*executorv1.FieldViolation
+	total := 0
+	add := func(field string, size int) {
+		if size == 0 {
+			return
+		}
+		total += size
+		parts = append(parts, &executorv1.FieldViolation{Field: field, Description: fmt.Sprintf("%d bytes", size)})
+	}
+	if scriptFromInput(ctx) {
+		add("script", len(ctx.Script))
+	}
+	add("stdin", len(ctx.Stdin))
+	filesSize := 0
+	for _, f := range ctx.Files {
+		filesSize += len(f.Content)
+	}
+	add("files", filesSize)
+	if ctx.Inputs != nil {
+		add("inputs", len(inputsJSON(ctx.Inputs)))
+	}
+	if total <= maxInputSecretSize {
+		return nil
+	}
+	return &DetailedError{
+		Code:       CodeInvalidParameter,
+		Message:    fmt.Sprintf("script, stdin, files and inputs too large together: %d bytes (max: %d)", total, maxInputSecretSize),
+		Violations: parts,
+	}
+}
+
+// BuildInputSecret returns the Secret carrying per-execution data for the
+// script container (the script, stdin and input files), or nil if there is none.
+// The Secret is owned by the created Job so it is garbage-collected with it.
+func (b *JobBuilder) BuildInputSecret(ctx *Context, job *batchv1.Job) *corev1.Secret {
+	if !hasInput(ctx) {
+		return nil
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      inputSecretName(job.Name),
+			Namespace: job.Namespace,
+			Labels: map[string]string{
+				"executor":     "script",
+				"execution-id": ctx.ExecutionID,
+				"managed-by":   "opscontrolroom",
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         "batch/v1",
+					Kind:               "Job",
+					Name:               job.Name,
+					UID:                job.UID,
+					Controller:         ptr.To(true),
+				},
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{},
+	}
+	if ctx.Stdin != "" {
+		secret.Data[inputStdinKey] = []byte(ctx.Stdin)
+	}
+	if scriptFromInput(ctx) {
+		secret.Data[inputScriptKey] = []byte(ctx.Script)
+	}
+	for i, f := range ctx.Files {
+		secret.Data[inputFileKey(i)] = f.Content
+	}
+	if ctx.Inputs != nil {
+		secret.Data[inputInputsKey] = inputsJSON(ctx.Inputs)
+	}
+	return secret
+}
+
+// buildInputVolume exposes stdin, inputs.json and the input files of the
+// input Secret.
+func (b *JobBuilder) buildInputVolume(ctx *Context, jobName string) corev1.Volume {
+	var items []corev1.KeyToPath
+	if ctx.Stdin != "" {
+		items = append(items, corev1.KeyToPath{Key: inputStdinKey, Path: inputStdinKey})
+	}
+	if ctx.Inputs != nil {
+		items = append(items, corev1.KeyToPath{Key: inputInputsKey, Path: inputsFile})
+	}
+	for i, f := range ctx.Files {
+		item := corev1.KeyToPath{Key: inputFileKey(i), Path: inputFilesDir + "/" + f.Path}
+		if f.Executable {
+			item.Mode = ptr.To(int32(0550))
+		}
+		items = append(items, item)
+	}
+	return corev1.Volume{
+		Name: inputVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: inputSecretName(jobName),
+				Items:      items,
+				// Group-readable: the script runs as a non-root user in fs_group.
+				DefaultMode: ptr.To(int32(0440)),
+			},
+		},
+	}
+}
+
+// buildScriptVolume exposes only the script key of the input Secret, as
+// scriptPath.
+func (b *JobBuilder) buildScriptVolume(ctx *Context, jobName string) corev1.Volume {
+	return corev1.Volume{
+		Name: scriptVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: inputSecretName(jobName),
+				Items: []corev1.KeyToPath{
+					{Key: inputScriptKey, Path: scriptFile + ctx.ScriptExtension},
+				},
+				DefaultMode: ptr.To(int32(0440)),
+			},
+		},
+	}
+}
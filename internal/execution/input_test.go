@@ -0,0 +1,49 @@
+package execution
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rakeshavasarala/script-executor/internal/script"
+)
+
+func TestCheckInputSecretSize(t *testing.T) {
+	kb := func(n int) string { return strings.Repeat("x", n*1024) }
+	tests := []struct {
+		name    string
+		ctx     *Context
+		wantErr bool
+	}{
+		{
+			name: "each part within its limit and the sum fits",
+			ctx:  &Context{Script: kb(400), Stdin: kb(250), Files: []InputFile{{Path: "a", Content: []byte(kb(250))}}},
+		},
+		{
+			name:    "each part within its limit but the sum does not fit",
+			ctx:     &Context{Script: kb(500), Stdin: kb(256), Files: []InputFile{{Path: "a", Content: []byte(kb(256))}}},
+			wantErr: true,
+		},
+		{
+			name:    "bundle files and inputs count",
+			ctx:     &Context{Files: []InputFile{{Path: "a", Content: []byte(kb(900))}}, Inputs: map[string]interface{}{"x": kb(200)}},
+			wantErr: true,
+		},
+		{
+			name: "script_path scripts are not in the Secret",
+			ctx:  &Context{Script: kb(600), ScriptSource: &script.Source{Type: script.SourcePath}, Stdin: kb(256)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkInputSecretSize(tt.ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkInputSecretSize() = %v, want error %v", err, tt.wantErr)
+			}
+			var de *DetailedError
+			if err != nil && (!errors.As(err, &de) || de.Code != CodeInvalidParameter) {
+				t.Errorf("error = %v, want an %s DetailedError", err, CodeInvalidParameter)
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/script"
+	corev1 "k8s.io/api/core/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Input types.
+const (
+	InputString = "string"
+	InputInt    = "int"
+	InputBool   = "bool"
+	InputEnum   = "enum"
+	InputList   = "list"
+)
+
+const (
+	// inputEnvPrefix prefixes the environment variable of every input.
+	inputEnvPrefix = "OCR_INPUT_"
+	// inputInputsKey is the input Secret key holding inputs.json.
+	inputInputsKey = "inputs"
+	// inputsFile is where inputs.json is found in the input volume.
+	inputsFile = "inputs.json"
+	// maxInputsSize bounds the encoded inputs, which also travel in the Job
+	// spec as environment variables.
+	maxInputsSize = 32768
+)
+
+var inputNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseInputs reads the inputs parameter and checks it against the declared
+// inputs: those of the registry entry, else the input_schema parameter.
+// Without declarations, values are accepted as strings, numbers, booleans and
+// lists of them. Values are returned typed: string, int64, bool or
+// []interface{}.
+func parseInputs(params *structpb.Struct, source *script.Source) (map[string]interface{}, []*executorv1.FieldViolation) {
+	var violations []*executorv1.FieldViolation
+	violation := func(field, desc string) {
+		violations = append(violations, &executorv1.FieldViolation{Field: field, Description: desc})
+	}
+
+	var declared map[string]script.InputSpec
+	if source != nil && source.Inputs != nil {
+		declared = source.Inputs
+		if getMap(params, "input_schema") != nil {
+			violation("input_schema", "the script declares its inputs in the registry")
+		}
+	} else {
+		declared, violations = parseInputSchema(getMap(params, "input_schema"))
+	}
+
+	given := getMap(params, "inputs")
+	if given == nil && declared == nil {
+		return nil, violations
+	}
+
+	inputs := make(map[string]interface{})
+	envNames := make(map[string]string)
+	checkName := func(field, name string) bool {
+		if !inputNameRe.MatchString(name) {
+			violation(field, "name must be letters, digits and underscores, not starting with a digit")
+			return false
+		}
+		env := strings.ToUpper(name)
+		if other, ok := envNames[env]; ok && other != name {
+			violation(field, fmt.Sprintf("name collides with %q as %s%s", other, inputEnvPrefix, env))
+			return false
+		}
+		envNames[env] = name
+		return true
+	}
+
+	if given != nil {
+		for _, name := range sortedKeys(given.Fields) {
+			field := "inputs." + name
+			if !checkName(field, name) {
+				continue
+			}
+			if declared == nil {
+				v, err := inferInput(given.Fields[name])
+				if err != nil {
+					violation(field, err.Error())
+					continue
+				}
+				inputs[name] = v
+				continue
+			}
+			spec, ok := declared[name]
+			if !ok {
+				violation(field, "is not a declared input")
+				continue
+			}
+			v, err := convertInput(spec, given.Fields[name].AsInterface())
+			if err != nil {
+				violation(field, err.Error())
+				continue
+			}
+			inputs[name] = v
+		}
+	}
+
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := inputs[name]; ok || (given != nil && given.Fields[name] != nil) {
+			continue
+		}
+		spec := declared[name]
+		field := "inputs." + name
+		switch {
+		case spec.Default != nil:
+			if !checkName(field, name) {
+				continue
+			}
+			v, err := convertInput(spec, spec.Default)
+			if err != nil {
+				violation(field, "invalid default: "+err.Error())
+				continue
+			}
+			inputs[name] = v
+		case spec.Required:
+			violation(field, "is required")
+		}
+	}
+
+	if data, err := json.Marshal(inputs); err == nil && len(data) > maxInputsSize {
+		violation("inputs", fmt.Sprintf("encoded size %d bytes exceeds %d bytes", len(data), maxInputsSize))
+	}
+	return inputs, violations
+}
+
+// parseInputSchema reads the input_schema parameter: a map from input name to
+// {type, values, items, required, default}.
+func parseInputSchema(m *structpb.Struct) (map[string]script.InputSpec, []*executorv1.FieldViolation) {
+	if m == nil {
+		return nil, nil
+	}
+	var violations []*executorv1.FieldViolation
+	declared := make(map[string]script.InputSpec, len(m.Fields))
+	for _, name := range sortedKeys(m.Fields) {
+		field := "input_schema." + name
+		s := m.Fields[name].GetStructValue()
+		if s == nil {
+			violations = append(violations, &executorv1.FieldViolation{Field: field, Description: "must be an object with a type"})
+			continue
+		}
+		spec := script.InputSpec{
+			Type:     getString(s, "type", ""),
+			Values:   getStringSlice(s, "values"),
+			Items:    getString(s, "items", ""),
+			Required: getBool(s, "required"),
+		}
+		if v, ok := s.Fields["default"]; ok {
+			spec.Default = v.AsInterface()
+		}
+		if err := checkInputSpec(spec); err != nil {
+			violations = append(violations, &executorv1.FieldViolation{Field: field, Description: err.Error()})
+			continue
+		}
+		declared[name] = spec
+	}
+	return declared, violations
+}
+
+// checkInputSpec reports whether an input declaration is usable.
+func checkInputSpec(spec script.InputSpec) error {
+	switch spec.Type {
+	case InputString, InputInt, InputBool:
+	case InputEnum:
+		if len(spec.Values) == 0 {
+			return fmt.Errorf("enum needs values")
+		}
+	case InputList:
+		switch spec.Items {
+		case "", InputString, InputInt, InputBool:
+		default:
+			return fmt.Errorf("unknown list item type %q (string, int, bool)", spec.Items)
+		}
+	default:
+		return fmt.Errorf("unknown type %q (string, int, bool, enum, list)", spec.Type)
+	}
+	return nil
+}
+
+// convertInput checks v, as decoded from JSON or YAML, against spec. Numbers
+// and booleans may also be given as strings.
+func convertInput(spec script.InputSpec, v interface{}) (interface{}, error) {
+	if err := checkInputSpec(spec); err != nil {
+		return nil, err
+	}
+	switch spec.Type {
+	case InputString:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("must be a string")
+	case InputInt:
+		return toInt(v)
+	case InputBool:
+		return toBool(v)
+	case InputEnum:
+		s, ok := v.(string)
+		if ok {
+			for _, allowed := range spec.Values {
+				if s == allowed {
+					return s, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(spec.Values, ", "))
+	case InputList:
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("must be a list")
+		}
+		items := spec.Items
+		if items == "" {
+			items = InputString
+		}
+		out := make([]interface{}, 0, len(list))
+		for i, item := range list {
+			converted, err := convertInput(script.InputSpec{Type: items}, item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			if s, ok := converted.(string); ok && strings.Contains(s, "\n") {
+				return nil, fmt.Errorf("item %d: must not contain newlines", i)
+			}
+			out = append(out, converted)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", spec.Type)
+}
+
+// inferInput accepts an undeclared input: a string, number, boolean or list
+// of those.
+func inferInput(v *structpb.Value) (interface{}, error) {
+	switch k := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return k.StringValue, nil
+	case *structpb.Value_BoolValue:
+		return k.BoolValue, nil
+	case *structpb.Value_NumberValue:
+		return toInt(k.NumberValue)
+	case *structpb.Value_ListValue:
+		out := make([]interface{}, 0, len(k.ListValue.Values))
+		for i, item := range k.ListValue.Values {
+			if _, ok := item.GetKind().(*structpb.Value_ListValue); ok {
+				return nil, fmt.Errorf("item %d: lists must not be nested", i)
+			}
+			converted, err := inferInput(item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			if s, ok := converted.(string); ok && strings.Contains(s, "\n") {
+				return nil, fmt.Errorf("item %d: must not contain newlines", i)
+			}
+			out = append(out, converted)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("must be a string, integer, boolean or list")
+}
+
+func toInt(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case float64:
+		if n == math.Trunc(n) && math.Abs(n) < 1<<53 {
+			return int64(n), nil
+		}
+	case int:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case string:
+		if i, err := strconv.ParseInt(n, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("must be an integer")
+}
+
+func toBool(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		switch b {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("must be a boolean")
+}
+
+// inputEnv returns the OCR_INPUT_<NAME> variables of the inputs. Lists are
+// newline-separated.
+func inputEnv(inputs map[string]interface{}) []corev1.EnvVar {
+	env := make([]corev1.EnvVar, 0, len(inputs))
+	for _, name := range sortedKeys(inputs) {
+		var value string
+		if list, ok := inputs[name].([]interface{}); ok {
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = fmt.Sprint(item)
+			}
+			value = strings.Join(items, "\n")
+		} else {
+			value = fmt.Sprint(inputs[name])
+		}
+		env = append(env, corev1.EnvVar{Name: inputEnvPrefix + strings.ToUpper(name), Value: value})
+	}
+	return env
+}
+
+// inputsJSON encodes the inputs as written to inputs.json.
+func inputsJSON(inputs map[string]interface{}) []byte {
+	data, _ := json.MarshalIndent(inputs, "", "  ")
+	return append(data, '\n')
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
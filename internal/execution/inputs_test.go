@@ -0,0 +1,79 @@
+package execution
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rakeshavasarala/script-executor/internal/script"
+)
+
+func TestParseInputs(t *testing.T) {
+	registry := &script.Source{Inputs: map[string]script.InputSpec{
+		"env":   {Type: InputEnum, Values: []string{"staging", "prod"}, Required: true},
+		"count": {Type: InputInt, Default: 1},
+	}}
+	tests := []struct {
+		name       string
+		params     map[string]interface{}
+		source     *script.Source
+		want       map[string]interface{}
+		violations []string
+	}{
+		{name: "none"},
+		{
+			name:   "undeclared values are inferred",
+			params: map[string]interface{}{"inputs": map[string]interface{}{"name": "db", "n": 3, "ok": true}},
+			want:   map[string]interface{}{"name": "db", "n": int64(3), "ok": true},
+		},
+		{
+			name:   "registry declarations with a default",
+			params: map[string]interface{}{"inputs": map[string]interface{}{"env": "prod"}},
+			source: registry,
+			want:   map[string]interface{}{"env": "prod", "count": int64(1)},
+		},
+		{name: "missing required input", source: registry, violations: []string{"inputs.env"}},
+		{
+			name:       "value outside the enum",
+			params:     map[string]interface{}{"inputs": map[string]interface{}{"env": "dev"}},
+			source:     registry,
+			violations: []string{"inputs.env"},
+		},
+		{
+			name:       "undeclared input",
+			params:     map[string]interface{}{"inputs": map[string]interface{}{"env": "prod", "extra": "x"}},
+			source:     registry,
+			violations: []string{"inputs.extra"},
+		},
+		{
+			name:       "input_schema is ignored for registry scripts",
+			params:     map[string]interface{}{"inputs": map[string]interface{}{"env": "prod"}, "input_schema": map[string]interface{}{}},
+			source:     registry,
+			violations: []string{"input_schema"},
+		},
+		{
+			name:       "invalid name",
+			params:     map[string]interface{}{"inputs": map[string]interface{}{"1st": "x"}},
+			violations: []string{"inputs.1st"},
+		},
+		{
+			name:       "names colliding as environment variables",
+			params:     map[string]interface{}{"inputs": map[string]interface{}{"name": "a", "NAME": "b"}},
+			violations: []string{"inputs.name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, violations := parseInputs(mustStruct(t, tt.params), tt.source)
+			var fields []string
+			for _, v := range violations {
+				fields = append(fields, v.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.violations) {
+				t.Fatalf("violations = %v, want %v", violations, tt.violations)
+			}
+			if tt.violations == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseInputs() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package execution
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/rakeshavasarala/script-executor/internal/artifact"
+)
+
+// RunJanitor deletes what executions leave behind once it expired, until ctx
+// is done: execution records, stored logs and artifacts past their retention,
+// and persistent workspaces of runbooks whose last step never released them.
+// First it finishes the executions no executor is monitoring anymore.
+func (m *Manager) RunJanitor(ctx context.Context) {
+	ticker := time.NewTicker(m.config.JanitorInterval())
+	defer ticker.Stop()
+	for {
+		m.reconcileRecords(ctx)
+		m.pruneRecords(ctx)
+		m.pruneLogs(ctx)
+		m.pruneArtifacts(ctx)
+		m.collectWorkspaces(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pruneRecords deletes the execution records past their retention.
+func (m *Manager) pruneRecords(ctx context.Context) {
+	n, err := m.records.Prune(ctx, time.Now().Add(-m.config.RecordRetention()))
+	if err != nil {
+		log.Printf("prune execution records: %v", err)
+	}
+	if n > 0 {
+		log.Printf("Deleted %d expired execution records", n)
+	}
+}
+
+// pruneLogs deletes the stored logs past their retention, and the oldest ones
+// beyond the log store's size limit.
+func (m *Manager) pruneLogs(ctx context.Context) {
+	if m.logs == nil {
+		return
+	}
+	cfg := m.config.ScriptExecutor.Execution.LogStore
+	n, err := m.logs.Prune(ctx, time.Now().Add(-m.config.LogRetention()), cfg.MaxSize)
+	if err != nil {
+		log.Printf("prune stored logs: %v", err)
+	}
+	if n > 0 {
+		log.Printf("Deleted %d stored logs", n)
+	}
+}
+
+// pruneArtifacts deletes the stored artifacts past their retention, and those
+// of the oldest executions beyond the store's size limit. Stores that cannot
+// prune rely on the backend's own expiry.
+func (m *Manager) pruneArtifacts(ctx context.Context) {
+	pruner, ok := m.artifacts.(artifact.Pruner)
+	if !ok {
+		return
+	}
+	cfg := m.config.ScriptExecutor.Execution.Artifacts
+	n, err := pruner.Prune(ctx, time.Now().Add(-m.config.ArtifactRetention()), cfg.MaxStoreSize)
+	if err != nil {
+		log.Printf("prune stored artifacts: %v", err)
+	}
+	if n > 0 {
+		log.Printf("Deleted the artifacts of %d executions", n)
+	}
+}
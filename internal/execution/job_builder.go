@@ -2,6 +2,7 @@ package execution
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/rakeshavasarala/script-executor/internal/config"
 	"github.com/rakeshavasarala/script-executor/internal/script"
@@ -55,7 +56,8 @@ func (b *JobBuilder) Build(ctx *Context) (*batchv1.Job, error) {
 		Spec: batchv1.JobSpec{
 			BackoffLimit:            ptr.To(ctx.BackoffLimit),
 			TTLSecondsAfterFinished: ptr.To(ctx.TTLSecondsAfterFinished),
-			ActiveDeadlineSeconds:   ptr.To(int64(ctx.Timeout.Seconds())),
+			// The Job deadline covers every phase; the monitor enforces each one.
+			ActiveDeadlineSeconds:   ptr.To(int64(jobDeadline(ctx).Seconds())),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
@@ -78,6 +80,7 @@ func (b *JobBuilder) Build(ctx *Context) (*batchv1.Job, error) {
 					NodeSelector:      ctx.NodeSelector,
 					Tolerations:       ctx.Tolerations,
 					Affinity:         ctx.Affinity,
+					TopologySpreadConstraints: ctx.TopologySpreadConstraints,
 					PriorityClassName: ctx.PriorityClassName,
 					Containers:       []corev1.Container{b.buildContainer(ctx)},
 					Volumes:          b.buildVolumes(ctx),
@@ -89,22 +92,145 @@ func (b *JobBuilder) Build(ctx *Context) (*batchv1.Job, error) {
 	for k, v := range ctx.Labels {
 		job.Labels[k] = v
 	}
+	if ctx.Workspace != nil {
+		// Lets the workspace janitor find the steps using a workspace
+		job.Labels[workspaceLabel] = ctx.Workspace.Claim
+		job.Spec.Template.Labels[workspaceLabel] = ctx.Workspace.Claim
+	}
 	for k, v := range ctx.Annotations {
 		job.Annotations[k] = v
 	}
 
+	// The script, stdin and input files are delivered as a mounted Secret
+	if hasInput(ctx) {
+		podSpec := &job.Spec.Template.Spec
+		container := &podSpec.Containers[0]
+		if scriptFromInput(ctx) {
+			podSpec.Volumes = append(podSpec.Volumes, b.buildScriptVolume(ctx, jobName))
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      scriptVolumeName,
+				MountPath: scriptMountPath,
+				ReadOnly:  true,
+			})
+		}
+		if mountsInput(ctx) {
+			podSpec.Volumes = append(podSpec.Volumes, b.buildInputVolume(ctx, jobName))
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      inputVolumeName,
+				MountPath: inputMountPath,
+				ReadOnly:  true,
+			})
+		}
+		if ctx.Stdin != "" {
+			container.Env = append(container.Env, corev1.EnvVar{
+				Name:  "OCR_STDIN",
+				Value: inputMountPath + "/" + inputStdinKey,
+			})
+		}
+		if ctx.Inputs != nil {
+			container.Env = append(container.Env, corev1.EnvVar{
+				Name:  "OCR_INPUTS",
+				Value: inputMountPath + "/" + inputsFile,
+			})
+			container.Env = append(container.Env, inputEnv(ctx.Inputs)...)
+		}
+		if len(ctx.Files) > 0 {
+			container.Env = append(container.Env, corev1.EnvVar{
+				Name:  "OCR_FILES",
+				Value: inputMountPath + "/" + inputFilesDir,
+			})
+			var sensitive []string
+			for _, f := range ctx.Files {
+				if f.Sensitive {
+					sensitive = append(sensitive, f.Path)
+				}
+			}
+			if len(sensitive) > 0 {
+				container.Env = append(container.Env, corev1.EnvVar{
+					Name:  "OCR_FILES_SENSITIVE",
+					Value: strings.Join(sensitive, "\n"),
+				})
+			}
+		}
+	}
+
 	if ctx.ImagePullSecret != "" {
 		job.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{
 			{Name: ctx.ImagePullSecret},
 		}
 	}
 
+	// A non-zero exit code the success criteria accept fails the Job at once:
+	// the executor decides the outcome, and a retry would run the script again
+	if codes := ctx.SuccessCriteria.acceptedFailureCodes(); len(codes) > 0 {
+		job.Spec.PodFailurePolicy = &batchv1.PodFailurePolicy{
+			Rules: []batchv1.PodFailurePolicyRule{{
+				Action: batchv1.PodFailurePolicyActionFailJob,
+				OnExitCodes: &batchv1.PodFailurePolicyOnExitCodesRequirement{
+					ContainerName: ptr.To("script"),
+					Operator:      batchv1.PodFailurePolicyOnExitCodesOpIn,
+					Values:        codes,
+				},
+			}},
+		}
+	}
+
+	// Catalog tools are copied into a shared volume before anything else runs
+	if len(ctx.Tools) > 0 {
+		podSpec := &job.Spec.Template.Spec
+		container := &podSpec.Containers[0]
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name:         toolsVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      toolsVolumeName,
+			MountPath: toolsMountPath,
+			ReadOnly:  true,
+		})
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "OCR_TOOLS_PATH",
+			Value: toolsPath(ctx.Tools),
+		})
+		for _, t := range ctx.Tools {
+			podSpec.InitContainers = append(podSpec.InitContainers, b.buildToolInit(ctx, t))
+			if t.PullSecret != "" && t.PullSecret != ctx.ImagePullSecret {
+				podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, corev1.LocalObjectReference{Name: t.PullSecret})
+			}
+		}
+	}
+
+	// Catalog init steps and sidecars, in the order requested
+	for _, s := range ctx.Sidecars {
+		podSpec := &job.Spec.Template.Spec
+		podSpec.InitContainers = append(podSpec.InitContainers, b.buildSidecar(ctx, s))
+		if s.PullSecret != "" && s.PullSecret != ctx.ImagePullSecret {
+			podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, corev1.LocalObjectReference{Name: s.PullSecret})
+		}
+	}
+
+	if ctx.Profile != "" {
+		return applyProfile(job, ctx.Profile, b.config.ScriptExecutor.JobProfiles)
+	}
 	return job, nil
 }
 
-func (b *JobBuilder) buildContainer(ctx *Context) corev1.Container {
+// containerSecurityContext is the hardened security context shared by the
+// script container, catalog sidecars and tool init containers.
+func (b *JobBuilder) containerSecurityContext() *corev1.SecurityContext {
 	secCfg := b.config.ScriptExecutor.Security
+	return &corev1.SecurityContext{
+		AllowPrivilegeEscalation: ptr.To(false),
+		ReadOnlyRootFilesystem:   ptr.To(secCfg.ReadOnlyRootFilesystem),
+		RunAsNonRoot:             ptr.To(secCfg.RunAsNonRoot),
+		RunAsUser:                ptr.To(secCfg.RunAsUser),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+}
 
+func (b *JobBuilder) buildContainer(ctx *Context) corev1.Container {
 	container := corev1.Container{
 		Name:            "script",
 		Image:           ctx.Image,
@@ -112,29 +238,25 @@ func (b *JobBuilder) buildContainer(ctx *Context) corev1.Container {
 		WorkingDir:      ctx.WorkingDir,
 		Env:             b.buildEnvVars(ctx),
 		EnvFrom:         b.buildEnvFrom(ctx),
-		SecurityContext: &corev1.SecurityContext{
-			AllowPrivilegeEscalation: ptr.To(false),
-			ReadOnlyRootFilesystem:   ptr.To(secCfg.ReadOnlyRootFilesystem),
-			RunAsNonRoot:             ptr.To(secCfg.RunAsNonRoot),
-			RunAsUser:                ptr.To(secCfg.RunAsUser),
-			Capabilities: &corev1.Capabilities{
-				Drop: []corev1.Capability{"ALL"},
-			},
-		},
+		SecurityContext: b.containerSecurityContext(),
 		Resources:    ctx.Resources,
 		VolumeMounts: b.buildVolumeMounts(ctx),
-		Stdin:        ctx.Stdin != "",
-		StdinOnce:    ctx.Stdin != "",
 	}
 
-	// Command and args depend on script source
-	if ctx.ScriptSource != nil && ctx.ScriptSource.Type == script.SourcePath {
-		container.Command = []string{ctx.Interpreter, ctx.ScriptSource.Path}
-		container.Args = ctx.Args
-	} else {
-		container.Command = []string{ctx.Interpreter, "-c", ctx.Script}
-		container.Args = ctx.Args
+	// The script is always run as a file: script_path scripts from the
+	// approved-scripts ConfigMap, bundle entrypoints from /workspace, anything
+	// else from the input Secret. It runs under the stream-tagging wrapper so
+	// stdout and stderr stay separable.
+	interpreter := append([]string{ctx.Interpreter}, ctx.InterpreterArgs...)
+	switch {
+	case scriptFromInput(ctx):
+		container.Command = wrapCommand(append(interpreter, scriptPath(ctx)))
+	case ctx.ScriptSource.Bundle != nil:
+		container.Command = wrapCommand(append(interpreter, "/workspace/"+ctx.ScriptSource.Bundle.Entrypoint))
+	default:
+		container.Command = wrapCommand(append(interpreter, ctx.ScriptSource.Path))
 	}
+	container.Args = ctx.Args
 
 	return container
 }
@@ -144,6 +266,21 @@ func (b *JobBuilder) buildEnvVars(ctx *Context) []corev1.EnvVar {
 	for k, v := range ctx.Env {
 		env = append(env, corev1.EnvVar{Name: k, Value: v})
 	}
+	// Structured outputs, collected by the run wrapper after the script
+	maxOutputs := b.config.ScriptExecutor.Security.MaxOutputsSize
+	if maxOutputs <= 0 {
+		maxOutputs = defaultMaxOutputsSize
+	}
+	env = append(env,
+		corev1.EnvVar{Name: "OCR_OUTPUTS", Value: outputsPath},
+		corev1.EnvVar{Name: "OCR_OUTPUTS_MAX", Value: fmt.Sprintf("%d", maxOutputs)},
+	)
+	if len(ctx.Artifacts) > 0 {
+		env = append(env,
+			corev1.EnvVar{Name: "OCR_ARTIFACTS", Value: strings.Join(ctx.Artifacts, "\n")},
+			corev1.EnvVar{Name: "OCR_ARTIFACTS_MAX", Value: fmt.Sprintf("%d", maxArtifactsSize(b.config))},
+		)
+	}
 	for name, ref := range ctx.EnvFromSecret {
 		if ref.SecretName == "" || ref.Key == "" {
 			continue
@@ -225,6 +362,13 @@ func (b *JobBuilder) buildVolumes(ctx *Context) []corev1.Volume {
 		},
 	}
 
+	// A persistent workspace outlives the pod
+	if ctx.Workspace != nil {
+		volumes[0].VolumeSource = corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: ctx.Workspace.Claim},
+		}
+	}
+
 	// approved-scripts for script_path
 	if ctx.ScriptSource != nil && ctx.ScriptSource.Type == script.SourcePath {
 		volumes = append(volumes, corev1.Volume{
@@ -0,0 +1,235 @@
+package execution
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/config"
+	"github.com/rakeshavasarala/script-executor/internal/security"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Language is the language profile an execution runs with.
+type Language struct {
+	Name    string
+	Profile config.LanguageProfile
+	// Command is the interpreter invocation; the script path is appended.
+	Command []string
+}
+
+// resolveLanguage picks the language profile for a script: the language
+// parameter, else the interpreter parameter, else the script's shebang, else
+// the configured default. A language, interpreter or shebang that matches no
+// profile is rejected rather than run blindly.
+func resolveLanguage(params *structpb.Struct, scriptContent string, cfg *config.Config) (*Language, error) {
+	languages := cfg.ScriptExecutor.Languages
+	interpreter := getString(params, "interpreter", "")
+	shebang := parseShebang(scriptContent)
+
+	var lang *Language
+	switch name := getString(params, "language", ""); {
+	case name != "":
+		profile, ok := languages.Profiles[name]
+		if !ok {
+			return nil, languageError("language", fmt.Sprintf("unknown language %q", name), "must be one of "+strings.Join(profileNames(languages), ", "))
+		}
+		lang = &Language{Name: name, Profile: profile}
+		if interpreter != "" && profileFor(languages, interpreter) != name {
+			return nil, languageError("interpreter", fmt.Sprintf("interpreter %q does not run %s", interpreter, name), "must be an interpreter of the language")
+		}
+	case interpreter != "":
+		name := profileFor(languages, interpreter)
+		if name == "" {
+			return nil, languageError("interpreter", fmt.Sprintf("unknown interpreter %q", interpreter), "must be an interpreter of one of "+strings.Join(profileNames(languages), ", "))
+		}
+		lang = &Language{Name: name, Profile: languages.Profiles[name]}
+	case shebang != nil:
+		name := profileFor(languages, shebang[0])
+		if name == "" {
+			return nil, languageError("script", fmt.Sprintf("unknown interpreter %q in shebang", shebang[0]), "shebang must name an interpreter of one of "+strings.Join(profileNames(languages), ", "))
+		}
+		lang = &Language{Name: name, Profile: languages.Profiles[name]}
+	default:
+		profile, ok := languages.Profiles[languages.Default]
+		if !ok {
+			return nil, fmt.Errorf("default language %q has no profile", languages.Default)
+		}
+		lang = &Language{Name: languages.Default, Profile: profile}
+	}
+
+	if len(lang.Profile.Command) == 0 {
+		return nil, fmt.Errorf("language %s has no command", lang.Name)
+	}
+	lang.Command = append([]string(nil), lang.Profile.Command...)
+	if interpreter != "" {
+		// An explicit interpreter replaces the profile's binary, but only with
+		// one the profile allows: any binary could otherwise be run under an
+		// interpreter's name.
+		allowed := append([]string{lang.Profile.Command[0]}, lang.Profile.InterpreterPaths...)
+		if !containsString(allowed, interpreter) {
+			return nil, languageError("interpreter", fmt.Sprintf("interpreter %q is not allowed for %s", interpreter, lang.Name), "must be one of "+strings.Join(allowed, ", "))
+		}
+		lang.Command[0] = interpreter
+	}
+	if shebang != nil && profileFor(languages, shebang[0]) == lang.Name {
+		// Keep interpreter options from the shebang, e.g. "#!/bin/bash -eu",
+		// but only harmless ones: the validators skip the shebang line.
+		if err := checkShebangOptions(lang.Profile.Validator, shebang[1:]); err != nil {
+			return nil, err
+		}
+		for _, arg := range shebang[1:] {
+			if !containsString(lang.Command, arg) {
+				lang.Command = append(lang.Command, arg)
+			}
+		}
+	}
+	// Options must come before the script; pwsh needs -File last.
+	if n := len(lang.Command); n > 1 && containsString(lang.Profile.Command, "-File") && lang.Command[n-1] != "-File" {
+		lang.Command = append(removeString(lang.Command, "-File"), "-File")
+	}
+	return lang, nil
+}
+
+// validatorFor returns the validator for a bundle file: the validator of the
+// profile whose extension it has, and the language's own for the entrypoint
+// and for files without a known extension, which the entrypoint may still
+// source or run.
+func (l *Language) validatorFor(cfg *config.Config, file string, entrypoint bool) string {
+	ext := path.Ext(file)
+	if entrypoint || ext == "" || ext == l.Profile.Extension {
+		return l.Profile.Validator
+	}
+	for _, name := range profileNames(cfg.ScriptExecutor.Languages) {
+		if p := cfg.ScriptExecutor.Languages.Profiles[name]; p.Extension == ext {
+			return p.Validator
+		}
+	}
+	return l.Profile.Validator
+}
+
+// apply sets the interpreter invocation of an execution.
+func (l *Language) apply(ctx *Context) {
+	ctx.Language = l.Name
+	ctx.Interpreter = l.Command[0]
+	ctx.InterpreterArgs = l.Command[1:]
+	ctx.ScriptExtension = l.Profile.Extension
+}
+
+// parseShebang returns the interpreter name and options of a "#!" first line,
+// looking through /usr/bin/env, or nil if there is none.
+func parseShebang(script string) []string {
+	if !strings.HasPrefix(script, "#!") {
+		return nil
+	}
+	line, _, _ := strings.Cut(script[2:], "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	if path.Base(fields[0]) == "env" {
+		fields = fields[1:]
+		for len(fields) > 0 && (strings.HasPrefix(fields[0], "-") || strings.Contains(fields[0], "=")) {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			return nil
+		}
+	}
+	return append([]string{path.Base(fields[0])}, fields[1:]...)
+}
+
+// shebangOptions are the interpreter options a shebang may pass, by
+// validator. Options that run code (bash -c, python -c, ruby -e), read startup
+// files (--rcfile, --init-file) or change the interpreter's mode are not
+// among them: the script's validator would never see what they run.
+var shebangOptions = map[string][]string{
+	security.ValidatorShell:  {"-e", "-u", "-x", "-v", "-o errexit", "-o nounset", "-o pipefail", "-o xtrace"},
+	security.ValidatorPython: {"-u", "-B", "-E", "-I", "-O", "-OO", "-s"},
+	security.ValidatorRuby:   {"-w"},
+}
+
+// checkShebangOptions rejects shebang options that are not in shebangOptions
+// for the validator. Shell flags may be combined, as in "-eux".
+func checkShebangOptions(validator string, args []string) error {
+	allowed := shebangOptions[validator]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		ok := containsString(allowed, arg)
+		switch {
+		case ok:
+		case validator == security.ValidatorShell && arg == "-o" && i+1 < len(args):
+			i++
+			arg += " " + args[i]
+			ok = containsString(allowed, arg)
+		case validator == security.ValidatorShell && len(arg) > 2 && arg[0] == '-' && arg[1] != '-':
+			ok = true
+			for _, flag := range arg[1:] {
+				if !containsString(allowed, "-"+string(flag)) {
+					ok = false
+				}
+			}
+		}
+		if !ok {
+			description := "the language takes no shebang options"
+			if len(allowed) > 0 {
+				description = "shebang options must be among " + strings.Join(allowed, ", ")
+			}
+			return languageError("script", fmt.Sprintf("shebang option %q is not allowed", arg), description)
+		}
+	}
+	return nil
+}
+
+// profileFor returns the profile an interpreter (a name or path) selects, or "".
+func profileFor(languages config.LanguagesConfig, interpreter string) string {
+	name := strings.TrimRight(path.Base(interpreter), "0123456789.")
+	for _, profile := range profileNames(languages) {
+		for _, i := range languages.Profiles[profile].Interpreters {
+			if i == name {
+				return profile
+			}
+		}
+	}
+	return ""
+}
+
+func profileNames(languages config.LanguagesConfig) []string {
+	names := make([]string, 0, len(languages.Profiles))
+	for name := range languages.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func languageError(field, message, description string) *DetailedError {
+	return &DetailedError{
+		Code:    CodeUnsupportedLanguage,
+		Message: message,
+		Violations: []*executorv1.FieldViolation{
+			{Field: field, Description: description},
+		},
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	out := list[:0:0]
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package execution
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rakeshavasarala/script-executor/internal/security"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestResolveLanguage(t *testing.T) {
+	cfg := testConfig(t)
+	tests := []struct {
+		name        string
+		params      map[string]interface{}
+		script      string
+		wantLang    string
+		wantCommand []string
+		wantErr     bool
+	}{
+		{name: "default", script: "echo hi", wantLang: "bash", wantCommand: []string{"/bin/bash"}},
+		{name: "language", params: map[string]interface{}{"language": "python"}, wantLang: "python", wantCommand: []string{"python3", "-u"}},
+		{name: "unknown language", params: map[string]interface{}{"language": "cobol"}, wantErr: true},
+		{name: "shebang", script: "#!/usr/bin/env python3\nprint(1)", wantLang: "python", wantCommand: []string{"python3", "-u"}},
+		{name: "shebang options", script: "#!/bin/bash -eu\necho", wantLang: "bash", wantCommand: []string{"/bin/bash", "-eu"}},
+		{name: "shebang set -o", script: "#!/bin/bash -e -o pipefail\necho", wantLang: "bash", wantCommand: []string{"/bin/bash", "-e", "-o", "pipefail"}},
+		{name: "shebang python options", script: "#!/usr/bin/python3 -B\nprint(1)", wantLang: "python", wantCommand: []string{"python3", "-u", "-B"}},
+		// The validator skips the shebang, so it would never see the command
+		{name: "shebang command string", script: "#!/bin/bash -c shutdown\necho", wantErr: true},
+		{name: "shebang combined command string", script: "#!/bin/bash -ec shutdown\necho", wantErr: true},
+		{name: "shebang rcfile", script: "#!/bin/bash --rcfile /tmp/x\necho", wantErr: true},
+		{name: "shebang init file", script: "#!/bin/bash --init-file /tmp/x\necho", wantErr: true},
+		{name: "shebang interactive", script: "#!/bin/sh -i\necho", wantErr: true},
+		{name: "shebang unknown set -o", script: "#!/bin/bash -o vi\necho", wantErr: true},
+		{name: "shebang assignment", script: "#!/bin/bash BASH_ENV=/tmp/x\necho", wantErr: true},
+		{name: "shebang python command", script: "#!/usr/bin/python3 -c import os\nprint(1)", wantErr: true},
+		{name: "shebang ruby code", script: "#!/usr/bin/ruby -e exit\nputs 1", wantErr: true},
+		{name: "shebang node options", script: "#!/usr/bin/env node --require /tmp/x.js\nconsole.log(1)", wantErr: true},
+		{name: "unknown shebang", script: "#!/usr/bin/perl\nprint 1", wantErr: true},
+		{
+			name:        "configured interpreter path",
+			params:      map[string]interface{}{"interpreter": "/usr/local/bin/python3"},
+			wantLang:    "python",
+			wantCommand: []string{"/usr/local/bin/python3", "-u"},
+		},
+		{
+			name:        "profile command as interpreter",
+			params:      map[string]interface{}{"language": "bash", "interpreter": "/bin/bash"},
+			wantLang:    "bash",
+			wantCommand: []string{"/bin/bash"},
+		},
+		// The basename selects python, but the binary is not one python allows
+		{name: "unconfigured interpreter path", params: map[string]interface{}{"interpreter": "/tmp/python3"}, wantErr: true},
+		{name: "interpreter of another language", params: map[string]interface{}{"language": "bash", "interpreter": "/usr/bin/python3"}, wantErr: true},
+		{name: "unknown interpreter", params: map[string]interface{}{"interpreter": "/usr/bin/perl"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := structpb.NewStruct(tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lang, err := resolveLanguage(params, tt.script, cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolveLanguage() = %s %v, want an error", lang.Name, lang.Command)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lang.Name != tt.wantLang || !reflect.DeepEqual(lang.Command, tt.wantCommand) {
+				t.Errorf("resolveLanguage() = %s %v, want %s %v", lang.Name, lang.Command, tt.wantLang, tt.wantCommand)
+			}
+		})
+	}
+}
+
+func TestValidatorFor(t *testing.T) {
+	cfg := testConfig(t)
+	shell := &Language{Name: "bash", Profile: cfg.ScriptExecutor.Languages.Profiles["bash"]}
+	python := &Language{Name: "python", Profile: cfg.ScriptExecutor.Languages.Profiles["python"]}
+	tests := []struct {
+		name       string
+		lang       *Language
+		file       string
+		entrypoint bool
+		want       string
+	}{
+		{name: "entrypoint", lang: python, file: "main", entrypoint: true, want: security.ValidatorPython},
+		{name: "own extension", lang: shell, file: "lib/common.sh", want: security.ValidatorShell},
+		{name: "other language's extension", lang: shell, file: "helpers/parse.py", want: security.ValidatorPython},
+		{name: "extensionless", lang: shell, file: "bin/helper", want: security.ValidatorShell},
+		{name: "unknown extension", lang: shell, file: "lib/common.inc", want: security.ValidatorShell},
+		{name: "unknown extension in python", lang: python, file: "data.cfg", want: security.ValidatorPython},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.lang.validatorFor(cfg, tt.file, tt.entrypoint); got != tt.want {
+				t.Errorf("validatorFor(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseShebang(t *testing.T) {
+	tests := []struct {
+		script string
+		want   []string
+	}{
+		{"echo hi", nil},
+		{"#!/bin/bash\necho", []string{"bash"}},
+		{"#!/bin/bash -eu\necho", []string{"bash", "-eu"}},
+		{"#!/usr/bin/env python3\n", []string{"python3"}},
+		{"#!/usr/bin/env -S PYTHONUNBUFFERED=1 python3 -u\n", []string{"python3", "-u"}},
+		{"#!/usr/bin/env\n", nil},
+		{"#!\n", nil},
+	}
+	for _, tt := range tests {
+		if got := parseShebang(tt.script); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseShebang(%q) = %v, want %v", tt.script, got, tt.want)
+		}
+	}
+}
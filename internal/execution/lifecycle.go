@@ -0,0 +1,317 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/record"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/utils/ptr"
+)
+
+// Policies for a running execution whose caller went away.
+const (
+	// OnDisconnectCancel deletes the Job.
+	OnDisconnectCancel = "cancel"
+	// OnDisconnectDetach keeps the Job running and monitors it in the background.
+	OnDisconnectDetach = "detach"
+)
+
+// ExecuteAsync launches a script.run step and returns as soon as its Job exists.
+// The outcome is written to the execution record by a background monitor.
+func (m *Manager) ExecuteAsync(ctx context.Context, req *executorv1.ExecuteRequest) (*executorv1.Execution, error) {
+	// Returning as soon as the Job exists, it must not block on an identical
+	// execution that is running
+	p, resp := m.prepare(ctx, req, false)
+	if resp != nil {
+		return m.rejected(ctx, req, resp), nil
+	}
+
+	job, err := m.launch(ctx, p)
+	if err != nil {
+		return m.rejected(ctx, req, errorResponse(err, p.startTime)), nil
+	}
+
+	// The caller is gone once we return, so monitor on a context of our own.
+	go m.complete(context.Background(), p, job, nil)
+
+	return &executorv1.Execution{
+		ExecutionId: p.context.ExecutionID,
+		State:       executorv1.Execution_STATE_RUNNING,
+		JobName:     job.Name,
+		CreatedAt:   timestamppb.New(p.startTime),
+	}, nil
+}
+
+// rejected reports a request that did not launch a Job, recording it unless a
+// launched execution with the same ID already exists.
+func (m *Manager) rejected(ctx context.Context, req *executorv1.ExecuteRequest, resp *executorv1.ExecuteResponse) *executorv1.Execution {
+	executionID := req.GetContext().GetExecutionId()
+	rec := &record.Record{
+		ExecutionID: executionID,
+		RunbookID:   req.GetContext().GetRunbookId(),
+		User:        req.GetContext().GetUser(),
+		State:       stateOf(resp),
+		CreatedAt:   time.Now(),
+	}
+	// A conflict belongs to another request's execution; leave its record alone.
+	if executionID == "" || resp.GetErrorDetails().GetCode() == CodeExecutionConflict {
+		return toExecution(rec, resp)
+	}
+
+	if existing, err := m.records.Get(ctx, executionID); err == nil && existing.JobName != "" {
+		// Replayed result of a finished execution.
+		return toExecution(existing, nil)
+	}
+
+	if resp.Status != executorv1.ExecuteResponse_STATUS_PENDING {
+		rec.FinishedAt = rec.CreatedAt
+	}
+	if raw, err := protojson.Marshal(resp); err == nil {
+		rec.Result = raw
+	}
+	if err := m.records.Save(ctx, rec); err != nil {
+		log.Printf("save execution record %s: %v", executionID, err)
+	}
+	return toExecution(rec, resp)
+}
+
+// GetExecution returns the current state of an execution.
+func (m *Manager) GetExecution(ctx context.Context, executionID string) (*executorv1.Execution, error) {
+	rec, err := m.records.Get(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	// The executor that launched it may be gone
+	rec = m.reconcile(ctx, rec)
+
+	// The pod name is only recorded on completion; look it up while running.
+	if rec.State == record.StateRunning && rec.PodName == "" && rec.JobName != "" {
+		pods, err := m.client.CoreV1().Pods(m.config.ScriptExecutor.Kubernetes.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("job-name=%s", rec.JobName),
+		})
+		if err == nil && len(pods.Items) > 0 {
+			rec.PodName = pods.Items[0].Name
+		}
+	}
+	return toExecution(rec, nil), nil
+}
+
+// CancelExecution deletes the Job of a running execution. Pods get gracePeriod to
+// shut down; zero means the configured cancel grace period.
+func (m *Manager) CancelExecution(ctx context.Context, executionID string, gracePeriod time.Duration, reason string) (*executorv1.Execution, error) {
+	if gracePeriod <= 0 {
+		gracePeriod = m.config.CancelGracePeriod()
+	}
+	if reason == "" {
+		reason = "cancelled by request"
+	}
+
+	// Mark the record first so the background monitor does not report the
+	// deleted Job as a failure. The update is conditional on the record's
+	// version, so it cannot overwrite a result finish stored meanwhile.
+	var resp *executorv1.ExecuteResponse
+	rec, err := record.Update(ctx, m.records, executionID, func(rec *record.Record) bool {
+		resp = nil
+		if rec.State.Terminal() {
+			return false
+		}
+		resp = &executorv1.ExecuteResponse{
+			Status: executorv1.ExecuteResponse_STATUS_FAILED,
+			Error:  fmt.Sprintf("execution cancelled: %s", reason),
+			ErrorDetails: &executorv1.ErrorDetails{
+				Code:    CodeCancelled,
+				Message: reason,
+			},
+			Duration: durationpbOf(time.Since(rec.CreatedAt)),
+		}
+		rec.State = record.StateCancelled
+		rec.CancelReason = reason
+		rec.FinishedAt = time.Now()
+		if raw, err := protojson.Marshal(resp); err == nil {
+			rec.Result = raw
+		}
+		return true
+	})
+	if errors.Is(err, record.ErrNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("save execution record: %w", err)
+	}
+	if resp == nil {
+		return toExecution(rec, nil), nil
+	}
+
+	if rec.JobName != "" {
+		if err := m.deleteJob(ctx, rec.JobName, gracePeriod); err != nil {
+			return nil, fmt.Errorf("delete job: %w", err)
+		}
+	}
+
+	if m.auditLog != nil {
+		m.auditLog.LogCancellation(rec.ExecutionID, rec.User, rec.RunbookID, rec.ScriptHash, reason)
+	}
+	return toExecution(rec, resp), nil
+}
+
+// disconnected applies the on_disconnect policy of an execution whose caller
+// went away while it was running. Either way the outcome ends up in the
+// execution record and the audit log; the returned response has no reader.
+func (m *Manager) disconnected(p *prepared, job *batchv1.Job) *executorv1.ExecuteResponse {
+	execContext := p.context
+	if execContext.OnDisconnect == OnDisconnectDetach {
+		log.Printf("Caller of execution %s went away; monitoring job %s in the background", execContext.ExecutionID, job.Name)
+		go m.complete(context.Background(), p, job, nil)
+		return &executorv1.ExecuteResponse{
+			Status:   executorv1.ExecuteResponse_STATUS_FAILED,
+			Error:    "caller disconnected; execution continues in the background",
+			Duration: durationpbOf(time.Since(p.startTime)),
+		}
+	}
+
+	// The cancelled execution has no result for identical requests waiting on it
+	m.releaseCached(execContext, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	reason := "caller disconnected"
+	exec, err := m.CancelExecution(ctx, execContext.ExecutionID, 0, reason)
+	if err != nil {
+		log.Printf("Failed to cancel execution %s after disconnect: %v", execContext.ExecutionID, err)
+
+		// Without a usable record, still stop the Job and audit it.
+		if err := m.deleteJob(ctx, job.Name, m.config.CancelGracePeriod()); err != nil {
+			log.Printf("Failed to delete job %s after disconnect: %v", job.Name, err)
+		}
+		if m.auditLog != nil {
+			m.auditLog.LogCancellation(execContext.ExecutionID, execContext.User, execContext.RunbookID, execContext.ScriptHash, reason)
+		}
+	} else if exec.Result != nil {
+		return exec.Result
+	}
+	return errorResponse(&DetailedError{Code: CodeCancelled, Message: reason}, p.startTime)
+}
+
+// deleteJob deletes a Job and its pods, giving the pods gracePeriod to exit.
+func (m *Manager) deleteJob(ctx context.Context, jobName string, gracePeriod time.Duration) error {
+	namespace := m.config.ScriptExecutor.Kubernetes.Namespace
+	grace := ptr.To(int64(gracePeriod.Seconds()))
+
+	// Job deletion grace applies to the Job object only, so delete the pods
+	// explicitly to pass the grace period through to the script.
+	err := m.client.CoreV1().Pods(namespace).DeleteCollection(ctx, metav1.DeleteOptions{
+		GracePeriodSeconds: grace,
+	}, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	err = m.client.BatchV1().Jobs(namespace).Delete(ctx, jobName, metav1.DeleteOptions{
+		GracePeriodSeconds: grace,
+		PropagationPolicy:  ptr.To(metav1.DeletePropagationBackground),
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// finish stores the terminal response in the execution record. If the
+// execution was cancelled meanwhile, the cancellation result wins.
+func (m *Manager) finish(executionID, podName string, resp *executorv1.ExecuteResponse) *executorv1.ExecuteResponse {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var cancelled *record.Record
+	_, err := record.Update(ctx, m.records, executionID, func(rec *record.Record) bool {
+		cancelled = nil
+		if rec.State == record.StateCancelled {
+			cancelled = rec
+			return false
+		}
+		rec.State = stateOf(resp)
+		rec.FinishedAt = time.Now()
+		if podName != "" {
+			rec.PodName = podName
+		}
+		if raw, err := protojson.Marshal(resp); err == nil {
+			rec.Result = raw
+		}
+		return true
+	})
+	if err != nil {
+		log.Printf("save execution record %s: %v", executionID, err)
+		return resp
+	}
+	if cancelled != nil {
+		if prev := decodeResult(cancelled); prev != nil {
+			return prev
+		}
+	}
+	return resp
+}
+
+func stateOf(resp *executorv1.ExecuteResponse) record.State {
+	switch resp.Status {
+	case executorv1.ExecuteResponse_STATUS_SUCCEEDED:
+		return record.StateSucceeded
+	case executorv1.ExecuteResponse_STATUS_TIMEOUT:
+		return record.StateTimeout
+	case executorv1.ExecuteResponse_STATUS_PENDING:
+		return record.StatePending
+	}
+	return record.StateFailed
+}
+
+func decodeResult(rec *record.Record) *executorv1.ExecuteResponse {
+	if len(rec.Result) == 0 {
+		return nil
+	}
+	var resp executorv1.ExecuteResponse
+	if err := protojson.Unmarshal(rec.Result, &resp); err != nil {
+		return nil
+	}
+	return &resp
+}
+
+// toExecution converts a record to its API form. resp overrides the stored result.
+func toExecution(rec *record.Record, resp *executorv1.ExecuteResponse) *executorv1.Execution {
+	if resp == nil {
+		resp = decodeResult(rec)
+	}
+	exec := &executorv1.Execution{
+		ExecutionId: rec.ExecutionID,
+		JobName:     rec.JobName,
+		PodName:     rec.PodName,
+		Result:      resp,
+		CreatedAt:   timestamppb.New(rec.CreatedAt),
+	}
+	if !rec.FinishedAt.IsZero() {
+		exec.FinishedAt = timestamppb.New(rec.FinishedAt)
+	}
+	switch rec.State {
+	case record.StatePending:
+		exec.State = executorv1.Execution_STATE_PENDING
+	case record.StateRunning:
+		exec.State = executorv1.Execution_STATE_RUNNING
+	case record.StateSucceeded:
+		exec.State = executorv1.Execution_STATE_SUCCEEDED
+	case record.StateFailed:
+		exec.State = executorv1.Execution_STATE_FAILED
+	case record.StateTimeout:
+		exec.State = executorv1.Execution_STATE_TIMEOUT
+	case record.StateCancelled:
+		exec.State = executorv1.Execution_STATE_CANCELLED
+	}
+	return exec
+}
@@ -0,0 +1,85 @@
+package execution
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/config"
+)
+
+// logChunkBytes is the maximum size of a LogChunk sent by GetLog.
+const logChunkBytes = 64 * 1024
+
+// ErrLogStoreDisabled is returned by GetLog when no log store is configured.
+var ErrLogStoreDisabled = errors.New("log store is not configured")
+
+// captureLimits returns the configured inline output limits.
+func captureLimits(cfg *config.Config) CaptureLimits {
+	out := cfg.ScriptExecutor.Execution.Output
+	return CaptureLimits{HeadBytes: out.HeadBytes, TailBytes: out.TailBytes}
+}
+
+// GetLog sends the stored log referenced by ref as chunks of consecutive lines
+// from the same stream. A non-empty stream ("stdout" or "stderr") filters the
+// log to that stream.
+func (m *Manager) GetLog(ctx context.Context, ref, stream string, send func(*executorv1.LogChunk) error) error {
+	if m.logs == nil {
+		return ErrLogStoreDisabled
+	}
+	rc, err := m.logs.Open(ctx, ref)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var chunk []byte
+	chunkStream := ""
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		err := send(&executorv1.LogChunk{Stream: chunkStream, Data: chunk})
+		chunk = nil
+		return err
+	}
+
+	br := bufio.NewReaderSize(rc, logChunkBytes)
+	lineStream := ""
+	for {
+		piece, err := br.ReadSlice('\n')
+		if len(piece) > 0 {
+			if lineStream == "" {
+				lineStream, piece = parseLogTag(piece)
+			}
+			wanted := (lineStream == streamStdout || lineStream == streamStderr) && (stream == "" || stream == lineStream)
+			if wanted {
+				if lineStream != chunkStream || len(chunk)+len(piece) > logChunkBytes {
+					if ferr := flush(); ferr != nil {
+						return ferr
+					}
+					chunkStream = lineStream
+				}
+				chunk = append(chunk, piece...)
+			}
+			if piece[len(piece)-1] == '\n' {
+				lineStream = ""
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return flush()
+}
@@ -4,22 +4,31 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/rakeshavasarala/script-executor/internal/approval"
+	"github.com/rakeshavasarala/script-executor/internal/artifact"
 	"github.com/rakeshavasarala/script-executor/internal/audit"
 	"github.com/rakeshavasarala/script-executor/internal/config"
 	"github.com/rakeshavasarala/script-executor/internal/image"
+	"github.com/rakeshavasarala/script-executor/internal/logstore"
+	"github.com/rakeshavasarala/script-executor/internal/record"
 	"github.com/rakeshavasarala/script-executor/internal/script"
 	"github.com/rakeshavasarala/script-executor/internal/security"
 	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/structpb"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/ptr"
 )
 
 // Manager orchestrates script execution.
@@ -35,6 +44,10 @@ type Manager struct {
 	jobBuilder *JobBuilder
 	monitor   *Monitor
 	auditLog  *audit.Logger
+	records   record.Store
+	logs      logstore.Store
+	artifacts artifact.Store
+	results   *resultCache
 }
 
 // NewManager creates an execution manager.
@@ -92,6 +105,15 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 		auditLogger, _ = audit.NewLogger(cfg.ScriptExecutor.Audit.LogFile, cfg.ScriptExecutor.Audit)
 	}
 
+	logs, err := logstore.New(cfg.ScriptExecutor.Execution.LogStore.Type, cfg.ScriptExecutor.Execution.LogStore.Path)
+	if err != nil {
+		return nil, fmt.Errorf("log store: %w", err)
+	}
+	artifacts, err := artifact.New(cfg.ScriptExecutor.Execution.Artifacts.Store)
+	if err != nil {
+		return nil, fmt.Errorf("artifact store: %w", err)
+	}
+
 	mgr := &Manager{
 		config:    cfg,
 		client:    client,
@@ -102,8 +124,12 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 		scriptVal: scriptValidator,
 		approval:  approvalChecker,
 		jobBuilder: NewJobBuilder(cfg),
-		monitor:   NewMonitor(client, namespace),
+		monitor:   NewMonitor(client, namespace, captureLimits(cfg), logs),
 		auditLog:  auditLogger,
+		records:   record.NewConfigMapStore(client, namespace, cfg.ScriptExecutor.Execution.Records.ConfigMapPrefix),
+		logs:      logs,
+		artifacts: artifacts,
+		results:   newResultCache(cfg),
 	}
 	return mgr, nil
 }
@@ -145,6 +171,9 @@ func NewManagerWithClient(cfg *config.Config, client kubernetes.Interface) *Mana
 		auditLogger, _ = audit.NewLogger(cfg.ScriptExecutor.Audit.LogFile, cfg.ScriptExecutor.Audit)
 	}
 
+	logs, _ := logstore.New(cfg.ScriptExecutor.Execution.LogStore.Type, cfg.ScriptExecutor.Execution.LogStore.Path)
+	artifacts, _ := artifact.New(cfg.ScriptExecutor.Execution.Artifacts.Store)
+
 	return &Manager{
 		config:    cfg,
 		client:    client,
@@ -155,13 +184,63 @@ func NewManagerWithClient(cfg *config.Config, client kubernetes.Interface) *Mana
 		scriptVal: scriptValidator,
 		approval:  approvalChecker,
 		jobBuilder: NewJobBuilder(cfg),
-		monitor:   NewMonitor(client, namespace),
+		monitor:   NewMonitor(client, namespace, captureLimits(cfg), logs),
 		auditLog:  auditLogger,
+		records:   record.NewConfigMapStore(client, namespace, cfg.ScriptExecutor.Execution.Records.ConfigMapPrefix),
+		logs:      logs,
+		artifacts: artifacts,
+		results:   newResultCache(cfg),
+	}
+}
+
+// prepared holds a validated request that is ready to be launched as a Job.
+type prepared struct {
+	context   *Context
+	job       *batchv1.Job
+	timeout   time.Duration
+	deadlines Deadlines
+	startTime time.Time
+	// outputParams are the output parameters of the request, as stored in
+	// the execution record
+	outputParams json.RawMessage
+}
+
+// bindDeadline caps the Job deadline at the caller's context deadline, so the
+// Job does not outlive a caller that is waiting for it. Detached executions
+// are meant to outlive the caller and keep their own deadlines.
+func (p *prepared) bindDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok || p.context.OnDisconnect == OnDisconnectDetach {
+		return
+	}
+	remaining := int64(time.Until(deadline).Seconds())
+	if remaining < 1 {
+		remaining = 1
+	}
+	if cur := p.job.Spec.ActiveDeadlineSeconds; cur == nil || *cur > remaining {
+		p.job.Spec.ActiveDeadlineSeconds = ptr.To(remaining)
 	}
 }
 
-// Execute runs a script.run step.
+// Execute runs a script.run step and waits for it to finish.
 func (m *Manager) Execute(ctx context.Context, req *executorv1.ExecuteRequest) (*executorv1.ExecuteResponse, error) {
+	p, resp := m.prepare(ctx, req, true)
+	if resp != nil {
+		return resp, nil
+	}
+	p.bindDeadline(ctx)
+	job, err := m.launch(ctx, p)
+	if err != nil {
+		return errorResponse(err, p.startTime), nil
+	}
+	return m.complete(ctx, p, job, nil), nil
+}
+
+// prepare loads, validates and approves the script and builds its Job. When the
+// request cannot (or may not yet) run, it returns the response to send instead.
+// wait is set for callers that wait for the result anyway, which may share
+// that of an identical execution still running.
+func (m *Manager) prepare(ctx context.Context, req *executorv1.ExecuteRequest, wait bool) (*prepared, *executorv1.ExecuteResponse) {
 	startTime := time.Now()
 	params := req.Parameters
 	if params == nil {
@@ -182,20 +261,53 @@ func (m *Manager) Execute(ctx context.Context, req *executorv1.ExecuteRequest) (
 	// 1. Load script
 	scriptContent, source, err := m.loader.LoadScript(ctx, params)
 	if err != nil {
-		return errorResponse(err, startTime), nil
+		return nil, errorResponse(err, startTime)
+	}
+	if getBool(params, "template") {
+		// The rendered script is what is validated, hashed and approved
+		if scriptContent, err = renderScript(scriptContent, source, params, execCtx); err != nil {
+			return nil, errorResponse(err, startTime)
+		}
+		source.Content = scriptContent
+	}
+
+	// 2. Resolve the language from the language or interpreter parameter, or
+	// the shebang of the script (a bundle's entrypoint)
+	shebangSource := scriptContent
+	if source.Bundle != nil {
+		shebangSource = string(source.Bundle.File(source.Bundle.Entrypoint).Content)
+	}
+	lang, err := resolveLanguage(params, shebangSource, m.config)
+	if err != nil {
+		return nil, errorResponse(err, startTime)
 	}
 
 	// For script_path, we don't have content - validation is skipped for path
-	if source.Type != script.SourcePath && scriptContent != "" {
-		// 2. Validate script
-		if err := m.scriptVal.Validate(scriptContent); err != nil {
-			return errorResponse(fmt.Errorf("script validation: %w", err), startTime), nil
+	if source.Bundle != nil {
+		// 2a. Validate every file of a bundle; binary content is checked as
+		// text too, since a shell will run whatever lines it finds in it
+		for _, f := range source.Bundle.Files {
+			validator := lang.validatorFor(m.config, f.Path, f.Path == source.Bundle.Entrypoint)
+			if err := m.scriptVal.ValidateAs(string(f.Content), validator); err != nil {
+				return nil, errorResponse(fmt.Errorf("script validation: %s: %w", f.Path, err), startTime)
+			}
+		}
+	} else if source.Type != script.SourcePath && scriptContent != "" {
+		// 2a. Validate script
+		if err := m.scriptVal.ValidateAs(scriptContent, lang.Profile.Validator); err != nil {
+			return nil, errorResponse(fmt.Errorf("script validation: %w", err), startTime)
 		}
 	}
 
-	// 3. Script hash (for inline/configmap/secret)
+	// 3. Script hash (for inline/configmap/secret; bundles are hashed as a
+	// whole, and approved scripts by path and approved-scripts version)
 	scriptHash := ""
-	if scriptContent != "" {
+	if source.Bundle != nil {
+		scriptHash = source.Bundle.Hash
+	} else if source.Type == script.SourcePath {
+		h := sha256.Sum256([]byte(source.Path + "\x00" + source.Version))
+		scriptHash = hex.EncodeToString(h[:])
+	} else if scriptContent != "" {
 		h := sha256.Sum256([]byte(scriptContent))
 		scriptHash = hex.EncodeToString(h[:])
 	}
@@ -203,17 +315,29 @@ func (m *Manager) Execute(ctx context.Context, req *executorv1.ExecuteRequest) (
 	// 4. Resolve image
 	imageStr := getString(params, "image", "")
 	imageRef := getString(params, "image_ref", "")
+	if imageStr == "" && imageRef == "" {
+		// Fall back to the language's default image
+		imageRef = lang.Profile.ImageRef
+	}
 	imagePullPolicy := getString(params, "image_pull_policy", "")
 	imagePullSecret := getString(params, "image_pull_secret", "")
 
 	resolved, err := m.resolver.Resolve(ctx, imageStr, imageRef, imagePullPolicy, imagePullSecret)
 	if err != nil {
-		return errorResponse(fmt.Errorf("resolve image: %w", err), startTime), nil
+		return nil, errorResponse(fmt.Errorf("resolve image: %w", err), startTime)
 	}
 
 	// 5. Validate image
 	if err := m.validator.Validate(resolved.Image); err != nil {
-		return errorResponse(fmt.Errorf("image validation: %w", err), startTime), nil
+		return nil, errorResponse(fmt.Errorf("image validation: %w", err), startTime)
+	}
+	sidecars, err := m.resolveSidecars(ctx, params)
+	if err != nil {
+		return nil, errorResponse(err, startTime)
+	}
+	tools, err := m.resolveTools(ctx, params)
+	if err != nil {
+		return nil, errorResponse(err, startTime)
 	}
 
 	// 6. Check approval
@@ -226,66 +350,247 @@ func (m *Manager) Execute(ctx context.Context, req *executorv1.ExecuteRequest) (
 		stepName := getString(params, "step_name", "default")
 		status, err := m.approval.Check(ctx, executionID, stepName, scriptContent, scriptHash, approvers, user)
 		if err != nil {
-			return errorResponse(err, startTime), nil
+			return nil, errorResponse(err, startTime)
 		}
 		if status == approval.StatusPending {
 			// Create approval request and return PENDING
 			if err := m.approval.CreateRequest(ctx, executionID, stepName, runbookID, user, scriptContent, scriptHash, approvers); err != nil {
-				return errorResponse(err, startTime), nil
+				return nil, errorResponse(err, startTime)
 			}
-			return &executorv1.ExecuteResponse{
+			return nil, &executorv1.ExecuteResponse{
 				Status:   executorv1.ExecuteResponse_STATUS_PENDING,
 				Error:    "Awaiting approval",
 				Duration: durationpbOf(time.Since(startTime)),
-			}, nil
+			}
 		}
 		if status == approval.StatusDenied {
-			return errorResponse(fmt.Errorf("execution was denied"), startTime), nil
+			return nil, errorResponse(fmt.Errorf("execution was denied"), startTime)
 		}
 	}
 
 	// 7. Build execution context
 	execContext, err := BuildContext(
-		params, execCtx,
+		params, execCtx, req.GetTimeout().AsDuration(),
 		scriptContent, source, scriptHash,
 		resolved.Image, string(resolved.PullPolicy), resolved.PullSecret,
 		m.config,
 	)
 	if err != nil {
-		return errorResponse(err, startTime), nil
+		return nil, errorResponse(err, startTime)
+	}
+	lang.apply(execContext)
+	execContext.Sidecars = sidecars
+	execContext.Tools = tools
+	if len(execContext.Artifacts) > 0 && m.artifacts == nil {
+		return nil, errorResponse(&DetailedError{
+			Code:    CodeInvalidParameter,
+			Message: "artifacts requested but no artifact store is configured",
+			Violations: []*executorv1.FieldViolation{
+				{Field: "artifacts", Description: ErrArtifactStoreDisabled.Error()},
+			},
+		}, startTime)
 	}
 	execContext.ExecutionID = executionID
 	execContext.RunbookID = runbookID
 	execContext.User = user
 
-	// 8. Build and create Job
+	// 8. Build Job
 	job, err := m.jobBuilder.Build(execContext)
 	if err != nil {
-		return errorResponse(fmt.Errorf("build job: %w", err), startTime), nil
+		return nil, errorResponse(fmt.Errorf("build job: %w", err), startTime)
 	}
 
-	created, err := m.client.BatchV1().Jobs(m.config.ScriptExecutor.Kubernetes.Namespace).Create(ctx, job, metav1.CreateOptions{})
-	if err != nil {
-		return errorResponse(fmt.Errorf("create job: %w", err), startTime), nil
+	p := &prepared{
+		context:   execContext,
+		job:       job,
+		timeout:   execContext.Timeout,
+		deadlines: deadlinesFor(execContext),
+		startTime: startTime,
+	}
+	p.outputParams = encodeOutputParams(params)
+
+	// 9. A retried request for an execution that already finished gets the
+	// original result instead of a second run.
+	if resp, err := m.replay(ctx, p); err != nil {
+		return nil, errorResponse(err, startTime)
+	} else if resp != nil {
+		return nil, resp
 	}
 
-	// 9. Wait for completion
-	timeout := execContext.Timeout
-	if req.Timeout != nil && req.Timeout.AsDuration() > 0 {
-		timeout = req.Timeout.AsDuration()
+	// 10. Reuse a recent result of an identical execution, or wait for one
+	// that is running
+	if execContext.CacheTTL > 0 {
+		resp, err := m.cachedResult(ctx, p, params, wait)
+		if err != nil {
+			return nil, errorResponse(err, startTime)
+		}
+		if resp != nil {
+			m.auditCached(execContext, resp)
+			return nil, resp
+		}
 	}
-	result, err := m.monitor.Wait(ctx, created, timeout+30*time.Second)
-	if err != nil {
-		return errorResponse(fmt.Errorf("wait for job: %w", err), startTime), nil
+
+	return p, nil
+}
+
+// replay returns the stored result when the execution ID was already run to
+// completion with the same script and image.
+func (m *Manager) replay(ctx context.Context, p *prepared) (*executorv1.ExecuteResponse, error) {
+	rec, err := m.records.Get(ctx, p.context.ExecutionID)
+	if err != nil || rec.JobName == "" || !rec.State.Terminal() {
+		return nil, nil
+	}
+	if err := checkSameExecution(p.context, rec.ScriptHash, rec.Image); err != nil {
+		return nil, err
 	}
+	return decodeResult(rec), nil
+}
 
-	// 10. Audit log
-	if m.auditLog != nil {
-		m.auditLog.LogExecution(executionID, user, runbookID, scriptHash, source, result.Succeeded, result.Duration, result.ExitCode)
+// checkSameExecution rejects reuse of an execution ID for different content.
+func checkSameExecution(ctx *Context, scriptHash, image string) error {
+	if scriptHash == ctx.ScriptHash && image == ctx.Image {
+		return nil
+	}
+	return &DetailedError{
+		Code:    CodeExecutionConflict,
+		Message: fmt.Sprintf("execution %s already exists with a different script or image", ctx.ExecutionID),
+		Metadata: map[string]string{
+			"execution_id":         ctx.ExecutionID,
+			"existing_script_hash": scriptHash,
+			"existing_image":       image,
+			"script_hash":          ctx.ScriptHash,
+			"image":                ctx.Image,
+		},
 	}
+}
 
-	// 11. Build response
-	output := buildOutput(execContext, result)
+// launch creates the Job and its execution record. If a Job for the same
+// execution already exists (a retried request), it is reattached instead.
+func (m *Manager) launch(ctx context.Context, p *prepared) (job *batchv1.Job, err error) {
+	defer func() {
+		if err != nil {
+			// Requests waiting for this execution's result won't get one
+			m.releaseCached(p.context, nil)
+		}
+	}()
+	if p.context.Workspace != nil {
+		if err := m.ensureWorkspace(ctx, p.context); err != nil {
+			return nil, err
+		}
+	}
+	jobs := m.client.BatchV1().Jobs(m.config.ScriptExecutor.Kubernetes.Namespace)
+	created, err := jobs.Create(ctx, p.job, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		existing, getErr := jobs.Get(ctx, p.job.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return nil, fmt.Errorf("get existing job: %w", getErr)
+		}
+		if existing.Labels["execution-id"] != p.context.ExecutionID {
+			return nil, fmt.Errorf("create job: %w", err)
+		}
+		if err := checkSameExecution(p.context, existing.Annotations["script-hash"], existing.Annotations["image"]); err != nil {
+			return nil, err
+		}
+		if _, err := m.records.Get(ctx, p.context.ExecutionID); err == nil {
+			return existing, nil
+		}
+		created = existing
+	} else if err != nil {
+		return nil, fmt.Errorf("create job: %w", err)
+	} else if err := m.createInputSecret(ctx, p.context, created); err != nil {
+		m.deleteJob(ctx, created.Name, 0)
+		return nil, err
+	}
+
+	rec := &record.Record{
+		ExecutionID: p.context.ExecutionID,
+		RunbookID:   p.context.RunbookID,
+		User:        p.context.User,
+		State:       record.StateRunning,
+		JobName:     created.Name,
+		ScriptHash:  p.context.ScriptHash,
+		Image:       p.context.Image,
+		OutputParams: p.outputParams,
+		CreatedAt:   p.startTime,
+	}
+	if err := m.records.Save(ctx, rec); err != nil {
+		log.Printf("save execution record %s: %v", rec.ExecutionID, err)
+	}
+	return created, nil
+}
+
+// createInputSecret creates the per-execution input Secret. It is created after
+// the Job so it can be owned by it; the kubelet retries the volume mount until
+// the Secret exists.
+func (m *Manager) createInputSecret(ctx context.Context, execContext *Context, job *batchv1.Job) error {
+	secret := m.jobBuilder.BuildInputSecret(execContext, job)
+	if secret == nil {
+		return nil
+	}
+	_, err := m.client.CoreV1().Secrets(job.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("create input secret: %w", err)
+	}
+	return nil
+}
+
+// complete waits for the Job, writes the audit log and execution record, and
+// returns the final response. onPhase, if set, receives pod phase transitions.
+func (m *Manager) complete(ctx context.Context, p *prepared, job *batchv1.Job, onPhase PhaseFunc) *executorv1.ExecuteResponse {
+	execContext := p.context
+
+	// 9. Wait for completion
+	result, err := m.monitor.WaitWithPhases(ctx, job, p.deadlines, onPhase)
+	if err != nil && ctx.Err() != nil && (errors.Is(ctx.Err(), context.Canceled) || execContext.OnDisconnect == OnDisconnectDetach) {
+		return m.disconnected(p, job)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		// The caller stopped waiting; nobody is left to collect the result.
+		result = &Result{
+			JobName:  job.Name,
+			ExitCode: -1,
+			Failure:  timeoutFailure(CodeDeadlineExceeded, "caller deadline exceeded while waiting for job %s", job.Name),
+			Aborted:  true,
+			TimedOut: true,
+		}
+	} else if err != nil {
+		m.releaseCached(execContext, nil)
+		resp := errorResponse(fmt.Errorf("wait for job: %w", err), p.startTime)
+		m.auditExecution(execContext, &Result{JobName: job.Name, ExitCode: -1, Duration: time.Since(p.startTime)}, resp)
+		return m.finish(execContext.ExecutionID, "", resp)
+	}
+	if result.Aborted {
+		// The pod will never run; don't leave it retrying until the deadline.
+		result.Duration = time.Since(p.startTime)
+		if err := m.deleteJob(context.Background(), job.Name, 0); err != nil {
+			log.Printf("Failed to delete job %s after %s: %v", job.Name, result.Failure.Code, err)
+		}
+	}
+
+	// 10. Build response
+	resp := m.respond(ctx, execContext, result)
+
+	m.releaseCached(execContext, resp)
+
+	// 11. Audit log, with the final verdict
+	m.auditExecution(execContext, result, resp)
+	m.finishWorkspace(execContext)
+
+	return m.finish(execContext.ExecutionID, result.PodName, resp)
+}
+
+// respond builds the response to a finished Job: its output, derived outputs
+// and artifacts, and the verdict of the success criteria.
+func (m *Manager) respond(ctx context.Context, execContext *Context, result *Result) *executorv1.ExecuteResponse {
+	extra, deriveErr := m.deriveOutput(execContext, result)
+	artifacts, artifactsErr := m.storeArtifacts(ctx, execContext, result)
+	if artifacts != nil {
+		extra["artifacts"] = artifacts
+	}
+	if deriveErr == nil {
+		deriveErr = artifactsErr
+	}
+	output := buildOutput(execContext, result, extra)
 	resp := &executorv1.ExecuteResponse{
 		Status:   executorv1.ExecuteResponse_STATUS_SUCCEEDED,
 		Output:   output,
@@ -295,11 +600,59 @@ func (m *Manager) Execute(ctx context.Context, req *executorv1.ExecuteRequest) (
 		resp.Status = executorv1.ExecuteResponse_STATUS_FAILED
 		resp.Error = fmt.Sprintf("exit code %d", result.ExitCode)
 	}
+	if result.TimedOut {
+		resp.Status = executorv1.ExecuteResponse_STATUS_TIMEOUT
+	}
+	if f := result.Failure; f != nil {
+		resp.Error = f.Message
+		resp.ErrorDetails = &executorv1.ErrorDetails{
+			Code:     f.Code,
+			Message:  f.Message,
+			Metadata: f.Metadata,
+		}
+	}
+	truncErr := truncationError(execContext, result)
+	if c := execContext.SuccessCriteria; c != nil && result.Failure == nil && !result.Aborted && truncErr == nil {
+		applyVerdict(resp, c.evaluate(result, extra))
+	}
+	var de *DetailedError
+	if truncErr != nil && result.Failure == nil && !result.Aborted {
+		// Parsing and criteria saw part of the output; don't trust their verdict.
+		resp.Status = executorv1.ExecuteResponse_STATUS_FAILED
+		resp.Error = truncErr.Message
+		resp.ErrorDetails = truncErr.details()
+	} else if errors.As(deriveErr, &de) && resp.Status == executorv1.ExecuteResponse_STATUS_SUCCEEDED {
+		// Later steps depend on the outputs; don't pass on a partial result.
+		resp.Status = executorv1.ExecuteResponse_STATUS_FAILED
+		resp.Error = de.Message
+		resp.ErrorDetails = de.details()
+	}
+	return resp
+}
 
-	return resp, nil
+// auditExecution logs a finished execution with its final verdict.
+func (m *Manager) auditExecution(execContext *Context, result *Result, resp *executorv1.ExecuteResponse) {
+	if m.auditLog == nil {
+		return
+	}
+	m.auditLog.LogExecution(&audit.Execution{
+		ExecutionID: execContext.ExecutionID,
+		User:        execContext.User,
+		RunbookID:   execContext.RunbookID,
+		ScriptHash:  execContext.ScriptHash,
+		Source:      execContext.ScriptSource,
+		Succeeded:   resp.Status == executorv1.ExecuteResponse_STATUS_SUCCEEDED,
+		Duration:    result.Duration,
+		ExitCode:    result.ExitCode,
+		Stdin:       execContext.Stdin,
+		Files:       auditFiles(execContext.Files),
+		Inputs:      execContext.Inputs,
+	})
 }
 
-func buildOutput(ctx *Context, result *Result) *structpb.Struct {
+// buildOutput returns the response output: the script result, plus any extra
+// fields derived from it.
+func buildOutput(ctx *Context, result *Result, extra map[string]interface{}) *structpb.Struct {
 	fields := map[string]interface{}{
 		"exit_code":        float64(result.ExitCode),
 		"stdout":           result.Stdout,
@@ -308,17 +661,31 @@ func buildOutput(ctx *Context, result *Result) *structpb.Struct {
 		"script_hash":      ctx.ScriptHash,
 		"job_name":         result.JobName,
 		"pod_name":         result.PodName,
+		"stdout_bytes":     float64(result.StdoutBytes),
+		"stderr_bytes":     float64(result.StderrBytes),
+		"truncated":        result.Truncated,
+	}
+	if result.LogRef != "" {
+		fields["log_ref"] = result.LogRef
+	}
+	for k, v := range extra {
+		fields[k] = v
 	}
 	out, _ := structpb.NewStruct(fields)
 	return out
 }
 
 func errorResponse(err error, startTime time.Time) *executorv1.ExecuteResponse {
-	return &executorv1.ExecuteResponse{
+	resp := &executorv1.ExecuteResponse{
 		Status:   executorv1.ExecuteResponse_STATUS_FAILED,
 		Error:    err.Error(),
 		Duration: durationpbOf(time.Since(startTime)),
 	}
+	var de *DetailedError
+	if errors.As(err, &de) {
+		resp.ErrorDetails = de.details()
+	}
+	return resp
 }
 
 func durationpbOf(d time.Duration) *durationpb.Duration {
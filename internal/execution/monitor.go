@@ -1,27 +1,34 @@
 package execution
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"io"
+	"log"
 	"time"
 
+	"github.com/rakeshavasarala/script-executor/internal/logstore"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
 )
 
 // Monitor watches a Job until completion.
 type Monitor struct {
 	client    kubernetes.Interface
 	namespace string
+	limits    CaptureLimits
+	logs      logstore.Store
 }
 
-// NewMonitor creates a Job monitor.
-func NewMonitor(client kubernetes.Interface, namespace string) *Monitor {
-	return &Monitor{client: client, namespace: namespace}
+// NewMonitor creates a Job monitor. Output beyond limits is dropped from the
+// result; the full log is kept in logs, if set.
+func NewMonitor(client kubernetes.Interface, namespace string, limits CaptureLimits, logs logstore.Store) *Monitor {
+	return &Monitor{client: client, namespace: namespace, limits: limits, logs: logs}
 }
 
 // Result holds the execution result.
@@ -29,14 +36,54 @@ type Result struct {
 	ExitCode   int
 	Stdout     string
 	Stderr     string
+	StdoutBytes int64
+	StderrBytes int64
+	// Truncated is set when stdout or stderr exceeded the capture limits.
+	Truncated  bool
+	StdoutTruncated bool
+	StderrTruncated bool
+	// LogRef references the full log in the log store when Truncated.
+	LogRef     string
+	// Outputs is the raw content of the $OCR_OUTPUTS file.
+	Outputs    string
+	// OutputsTooLarge is the size of an $OCR_OUTPUTS file over the limit.
+	OutputsTooLarge int
+	// Artifacts are the workspace files matched by the artifacts parameter.
+	Artifacts  []*CollectedArtifact
 	Duration   time.Duration
 	JobName    string
 	PodName    string
 	Succeeded  bool
+	// Failure is set when the pod failed for reasons other than the script.
+	Failure    *Failure
+	// Aborted is set when the monitor gave up on a Job that is still active.
+	Aborted    bool
+	// TimedOut is set when a deadline stopped the execution.
+	TimedOut   bool
 }
 
+// Phase is a lifecycle milestone of the script pod.
+type Phase string
+
+const (
+	PhaseJobCreated   Phase = "job_created"
+	PhasePodScheduled Phase = "pod_scheduled"
+	PhaseImagePulled  Phase = "image_pulled"
+	PhaseRunning      Phase = "running"
+	PhaseCompleting   Phase = "completing"
+)
+
+// PhaseFunc is called once per phase, in order, as the script pod progresses.
+type PhaseFunc func(phase Phase, pod *corev1.Pod)
+
 // Wait waits for the Job to complete and returns the result.
 func (m *Monitor) Wait(ctx context.Context, job *batchv1.Job, timeout time.Duration) (*Result, error) {
+	return m.WaitWithPhases(ctx, job, Deadlines{Total: timeout}, nil)
+}
+
+// WaitWithPhases is Wait with per-phase deadlines, additionally reporting pod
+// phase transitions to onPhase.
+func (m *Monitor) WaitWithPhases(ctx context.Context, job *batchv1.Job, deadlines Deadlines, onPhase PhaseFunc) (*Result, error) {
 	watcher, err := m.client.BatchV1().Jobs(m.namespace).Watch(ctx, metav1.ListOptions{
 		FieldSelector: fmt.Sprintf("metadata.name=%s", job.Name),
 	})
@@ -45,14 +92,18 @@ func (m *Monitor) Wait(ctx context.Context, job *batchv1.Job, timeout time.Durat
 	}
 	defer watcher.Stop()
 
-	deadline := time.Now().Add(timeout)
+	tracker := &podTracker{onPhase: onPhase, reachedAt: map[Phase]time.Time{}}
+	tracker.report(PhaseJobCreated, nil)
+
+	deadline := time.Now().Add(deadlines.Total)
 	for {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
-			if time.Now().After(deadline) {
-				return nil, fmt.Errorf("job %s timed out after %v", job.Name, timeout)
+			if deadlines.Total > 0 && time.Now().After(deadline) {
+				f := timeoutFailure(CodeDeadlineExceeded, "job %s timed out after %v", job.Name, deadlines.Total)
+				return &Result{JobName: job.Name, ExitCode: -1, Failure: f, Aborted: true, TimedOut: true}, nil
 			}
 		}
 
@@ -64,7 +115,7 @@ func (m *Monitor) Wait(ctx context.Context, job *batchv1.Job, timeout time.Durat
 				if err != nil {
 					return nil, err
 				}
-				return m.collectResult(ctx, job)
+				return m.finishWait(ctx, job, tracker)
 			}
 			if event.Type == watch.Deleted {
 				return nil, fmt.Errorf("job %s was deleted", job.Name)
@@ -77,7 +128,7 @@ func (m *Monitor) Wait(ctx context.Context, job *batchv1.Job, timeout time.Durat
 				continue
 			}
 			if isJobComplete(j) || isJobFailed(j) {
-				return m.collectResult(ctx, j)
+				return m.finishWait(ctx, j, tracker)
 			}
 		case <-time.After(2 * time.Second):
 			// Re-fetch in case we missed events
@@ -86,10 +137,150 @@ func (m *Monitor) Wait(ctx context.Context, job *batchv1.Job, timeout time.Durat
 				return nil, err
 			}
 			if isJobComplete(j) || isJobFailed(j) {
-				return m.collectResult(ctx, j)
+				return m.finishWait(ctx, j, tracker)
+			}
+			pod, err := m.getPod(ctx, job.Name)
+			if err != nil {
+				continue
 			}
+			// Fail fast when the pod cannot make progress on its own
+			var failure *Failure
+			if pod != nil {
+				tracker.observe(pod)
+				failure = m.stuckFailure(ctx, pod)
+			} else {
+				failure = m.jobFailure(ctx, j)
+			}
+			timedOut := false
+			if failure == nil {
+				failure = tracker.overdue(deadlines, time.Now())
+				timedOut = failure != nil
+			}
+			if failure != nil {
+				res := &Result{JobName: j.Name, ExitCode: -1, Failure: failure, Aborted: true, TimedOut: timedOut}
+				if pod != nil {
+					res.PodName = pod.Name
+				}
+				return res, nil
+			}
+		}
+	}
+}
+
+func (m *Monitor) finishWait(ctx context.Context, job *batchv1.Job, tracker *podTracker) (*Result, error) {
+	if pod, err := m.getPod(ctx, job.Name); err == nil && pod != nil {
+		tracker.observe(pod)
+	}
+	return m.collectResult(ctx, job)
+}
+
+// getPod returns the (first) pod of a Job, or nil if none exists yet.
+func (m *Monitor) getPod(ctx context.Context, jobName string) (*corev1.Pod, error) {
+	pods, err := m.client.CoreV1().Pods(m.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, nil
+	}
+	return &pods.Items[0], nil
+}
+
+// podTracker derives phase transitions from pod status snapshots.
+type podTracker struct {
+	onPhase   PhaseFunc
+	reachedAt map[Phase]time.Time
+}
+
+func (t *podTracker) reached(phase Phase) bool {
+	_, ok := t.reachedAt[phase]
+	return ok
+}
+
+func (t *podTracker) report(phase Phase, pod *corev1.Pod) {
+	if t.reached(phase) {
+		return
+	}
+	t.reachedAt[phase] = time.Now()
+	if t.onPhase != nil {
+		t.onPhase(phase, pod)
+	}
+}
+
+// observe reports every phase the pod has reached, filling in any that were
+// skipped between polls so callers always see them in order.
+func (t *podTracker) observe(pod *corev1.Pod) {
+	reached := podPhase(pod)
+	for _, phase := range []Phase{PhasePodScheduled, PhaseImagePulled, PhaseRunning, PhaseCompleting} {
+		if phaseOrder(phase) > phaseOrder(reached) {
+			return
+		}
+		t.report(phase, pod)
+	}
+}
+
+func phaseOrder(p Phase) int {
+	switch p {
+	case PhasePodScheduled:
+		return 1
+	case PhaseImagePulled:
+		return 2
+	case PhaseRunning:
+		return 3
+	case PhaseCompleting:
+		return 4
+	}
+	return 0
+}
+
+// podPhase returns the furthest phase the script container has reached.
+func podPhase(pod *corev1.Pod) Phase {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name != "script" {
+			continue
+		}
+		switch {
+		case cs.State.Terminated != nil:
+			return PhaseCompleting
+		case cs.State.Running != nil:
+			return PhaseRunning
+		case cs.ImageID != "":
+			return PhaseImagePulled
+		}
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionTrue {
+			return PhasePodScheduled
+		}
+	}
+	return PhaseJobCreated
+}
+
+// FollowLogs streams the script's stdout and stderr lines from the container
+// log of a pod, in the order they were written, until the container exits or
+// ctx is cancelled.
+func (m *Monitor) FollowLogs(ctx context.Context, podName string, onLine func(stream, line string)) error {
+	req := m.client.CoreV1().Pods(m.namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: "script",
+		Follow:    true,
+	})
+	logStream, err := req.Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("follow logs: %w", err)
+	}
+	defer logStream.Close()
+
+	scanner := bufio.NewScanner(logStream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		stream, text := parseLogLine(scanner.Text())
+		if stream == streamStdout || stream == streamStderr {
+			onLine(stream, text)
 		}
 	}
+	return scanner.Err()
 }
 
 func isJobComplete(job *batchv1.Job) bool {
@@ -120,11 +311,21 @@ func (m *Monitor) collectResult(ctx context.Context, job *batchv1.Job) (*Result,
 	pods, err := m.client.CoreV1().Pods(m.namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: fmt.Sprintf("job-name=%s", job.Name),
 	})
+	if jobDeadlineExceeded(job) {
+		res.TimedOut = true
+		res.Failure = timeoutFailure(CodeDeadlineExceeded, "job %s exceeded its deadline of %ds", job.Name, ptr.Deref(job.Spec.ActiveDeadlineSeconds, 0))
+	}
 	if err != nil || len(pods.Items) == 0 {
+		if !res.Succeeded {
+			res.ExitCode = -1
+		}
 		return res, nil
 	}
 	pod := &pods.Items[0]
 	res.PodName = pod.Name
+	if !res.Succeeded && res.Failure == nil {
+		res.Failure = m.terminalFailure(ctx, pod)
+	}
 
 	// Duration from pod
 	if pod.Status.StartTime != nil {
@@ -137,26 +338,71 @@ func (m *Monitor) collectResult(ctx context.Context, job *batchv1.Job) (*Result,
 		res.Duration = endTime.Sub(pod.Status.StartTime.Time)
 	}
 
-	// Exit code and logs
+	// Exit code and logs; a script that never exited has none
+	if !res.Succeeded {
+		res.ExitCode = -1
+	}
 	for _, cs := range pod.Status.ContainerStatuses {
-		if cs.State.Terminated != nil {
+		if cs.Name == "script" && cs.State.Terminated != nil {
 			res.ExitCode = int(cs.State.Terminated.ExitCode)
 			break
 		}
 	}
 
-	// Fetch logs (stdout and stderr combined by default)
+	// Fetch logs; the run wrapper tagged each line with its stream
 	req := m.client.CoreV1().Pods(m.namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
 		Container: "script",
 	})
 	logStream, err := req.Stream(ctx)
 	if err == nil {
 		defer logStream.Close()
-		buf, _ := io.ReadAll(logStream)
-		if len(buf) > 0 {
-			res.Stdout = string(buf)
-		}
+		m.captureLogs(ctx, logStream, res)
 	}
 
 	return res, nil
 }
+
+// captureLogs fills the result from the pod log, spilling the full log to the
+// log store when it is too large to return inline.
+func (m *Monitor) captureLogs(ctx context.Context, logStream io.Reader, res *Result) {
+	var spill io.WriteCloser
+	var ref string
+	if m.logs != nil {
+		var err error
+		if spill, ref, err = m.logs.Create(ctx, res.JobName); err != nil {
+			log.Printf("Failed to create log for job %s: %v", res.JobName, err)
+			spill = nil
+		}
+	}
+
+	var w io.Writer
+	if spill != nil {
+		w = spill
+	}
+	captured, err := captureLog(logStream, m.limits, w)
+	if spill != nil {
+		spill.Close()
+	}
+	if err != nil {
+		log.Printf("Failed to read log of job %s: %v", res.JobName, err)
+	}
+	if captured == nil {
+		return
+	}
+
+	res.Stdout, res.Stderr = captured.Stdout, captured.Stderr
+	res.StdoutBytes, res.StderrBytes = captured.StdoutBytes, captured.StderrBytes
+	res.Truncated = captured.Truncated
+	res.StdoutTruncated, res.StderrTruncated = captured.StdoutTruncated, captured.StderrTruncated
+	res.Outputs, res.OutputsTooLarge = captured.Outputs, captured.OutputsTooLarge
+	res.Artifacts = captured.Artifacts
+
+	if spill == nil {
+		return
+	}
+	if res.Truncated {
+		res.LogRef = ref
+	} else if err := m.logs.Delete(ctx, ref); err != nil {
+		log.Printf("Failed to delete log %s: %v", ref, err)
+	}
+}
@@ -0,0 +1,167 @@
+package execution
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+)
+
+const (
+	// outputsPath is where the script writes its outputs ($OCR_OUTPUTS).
+	outputsPath = "/workspace/.ocr_outputs"
+	// defaultMaxOutputsSize applies when security.max_outputs_size is unset.
+	defaultMaxOutputsSize = 65536
+)
+
+// parseOutputs decodes the content of the $OCR_OUTPUTS file. A JSON object is
+// used as is. Otherwise every non-empty line that is not a # comment must be
+// key=value; a value that is valid JSON (number, bool, null, object, array,
+// quoted string) keeps its type, anything else is a string.
+func parseOutputs(content string) (map[string]interface{}, error) {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(trimmed, "{") {
+		var outputs map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &outputs); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return outputs, nil
+	}
+
+	outputs := make(map[string]interface{})
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: expected key=value", lineNo)
+		}
+		var typed interface{}
+		if err := json.Unmarshal([]byte(value), &typed); err != nil {
+			typed = value
+		}
+		outputs[key] = typed
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+// collectOutputs returns the script's outputs, or a DetailedError if they were
+// too large or could not be parsed.
+func collectOutputs(result *Result, maxSize int) (map[string]interface{}, error) {
+	if maxSize <= 0 {
+		maxSize = defaultMaxOutputsSize
+	}
+	if result.OutputsTooLarge > 0 || len(result.Outputs) > maxSize {
+		size := result.OutputsTooLarge
+		if size == 0 {
+			size = len(result.Outputs)
+		}
+		return nil, outputsError(fmt.Sprintf("outputs too large: %d bytes (max: %d)", size, maxSize),
+			fmt.Sprintf("must be at most %d bytes", maxSize))
+	}
+	outputs, err := parseOutputs(result.Outputs)
+	if err != nil {
+		return nil, outputsError(fmt.Sprintf("parse outputs: %v", err), err.Error())
+	}
+	return outputs, nil
+}
+
+func outputsError(message, description string) *DetailedError {
+	return &DetailedError{
+		Code:    CodeInvalidOutputs,
+		Message: message,
+		Violations: []*executorv1.FieldViolation{
+			{Field: "outputs", Description: description},
+		},
+	}
+}
+
+// truncationError returns a DetailedError when output parsing, extraction or
+// success criteria depend on a stream that was truncated: they would only see
+// its head and tail, so a parse could fail or a not_matches pattern pass
+// because the line it looks for was dropped.
+func truncationError(ctx *Context, result *Result) *DetailedError {
+	var violations []*executorv1.FieldViolation
+	used := func(field string, stream string) {
+		violations = append(violations, &executorv1.FieldViolation{Field: field, Description: stream + " exceeded the capture limits"})
+	}
+	if result.StdoutTruncated {
+		if ctx.OutputFormat != "" && ctx.OutputFormat != OutputFormatText {
+			used("output_format", streamStdout)
+		}
+		for _, name := range sortedKeys(ctx.Extract) {
+			used("extract."+name, streamStdout)
+		}
+		if c := ctx.SuccessCriteria; c != nil {
+			if len(c.StdoutMatches) > 0 {
+				used("success_criteria.stdout_matches", streamStdout)
+			}
+			if len(c.StdoutNotMatches) > 0 {
+				used("success_criteria.stdout_not_matches", streamStdout)
+			}
+		}
+	}
+	if c := ctx.SuccessCriteria; c != nil && result.StderrTruncated {
+		if len(c.StderrMatches) > 0 {
+			used("success_criteria.stderr_matches", streamStderr)
+		}
+		if len(c.StderrNotMatches) > 0 {
+			used("success_criteria.stderr_not_matches", streamStderr)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &DetailedError{
+		Code:       CodeOutputTruncated,
+		Message:    fmt.Sprintf("%s cannot be checked: %s", violations[0].Field, violations[0].Description),
+		Violations: violations,
+	}
+}
+
+// deriveOutput returns the output fields derived from the script result:
+// outputs, parsed and extracted. It returns a DetailedError for anything that
+// could not be derived, alongside the fields that could.
+func (m *Manager) deriveOutput(ctx *Context, result *Result) (map[string]interface{}, error) {
+	extra := map[string]interface{}{}
+	outputs, outputsErr := collectOutputs(result, m.config.ScriptExecutor.Security.MaxOutputsSize)
+	if outputs != nil {
+		extra["outputs"] = outputs
+	}
+
+	parsed, extracted, violations := parseOutput(ctx, result.Stdout)
+	if parsed != nil {
+		extra["parsed"] = parsed
+	}
+	if extracted != nil {
+		extra["extracted"] = extracted
+	}
+
+	if outputsErr != nil {
+		return extra, outputsErr
+	}
+	if len(violations) > 0 {
+		return extra, &DetailedError{
+			Code:       CodeOutputParseFailed,
+			Message:    fmt.Sprintf("parse output: %s: %s", violations[0].Field, violations[0].Description),
+			Violations: violations,
+		}
+	}
+	return extra, nil
+}
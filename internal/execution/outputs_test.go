@@ -0,0 +1,87 @@
+package execution
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{name: "empty", content: "  \n"},
+		{
+			name:    "typed key=value",
+			content: "# a comment\ncount=3\nok=true\nnone=null\nname=web-1\nquoted=\"a b\"\nlist=[1,2]\nobj={\"a\":1}\n\nempty=\nspaced = value\n",
+			want: map[string]interface{}{
+				"count":  float64(3),
+				"ok":     true,
+				"none":   nil,
+				"name":   "web-1",
+				"quoted": "a b",
+				"list":   []interface{}{float64(1), float64(2)},
+				"obj":    map[string]interface{}{"a": float64(1)},
+				"empty":  "",
+				"spaced": " value",
+			},
+		},
+		{name: "value with an equals sign", content: "url=http://x/?a=b", want: map[string]interface{}{"url": "http://x/?a=b"}},
+		{
+			name:    "JSON object",
+			content: "\n{\"count\": 3, \"nested\": {\"ok\": true}}\n",
+			want:    map[string]interface{}{"count": float64(3), "nested": map[string]interface{}{"ok": true}},
+		},
+		{name: "invalid JSON object", content: "{\"count\": ", wantErr: true},
+		{name: "line without equals", content: "a=1\noops\n", wantErr: true},
+		{name: "empty key", content: "=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOutputs(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOutputs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseOutputs() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectOutputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   *Result
+		maxSize  int
+		want     map[string]interface{}
+		wantCode string
+	}{
+		{name: "within the limit", result: &Result{Outputs: "a=1"}, maxSize: 10, want: map[string]interface{}{"a": float64(1)}},
+		{name: "over the limit", result: &Result{Outputs: "a=" + strings.Repeat("x", 10)}, maxSize: 10, wantCode: CodeInvalidOutputs},
+		{name: "file too large to read", result: &Result{OutputsTooLarge: 1 << 20}, maxSize: 10, wantCode: CodeInvalidOutputs},
+		{name: "default limit", result: &Result{Outputs: "a=" + strings.Repeat("x", defaultMaxOutputsSize)}, wantCode: CodeInvalidOutputs},
+		{name: "unparsable", result: &Result{Outputs: "oops"}, wantCode: CodeInvalidOutputs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectOutputs(tt.result, tt.maxSize)
+			if tt.wantCode != "" {
+				de, ok := err.(*DetailedError)
+				if !ok || de.Code != tt.wantCode || len(de.Violations) != 1 || de.Violations[0].Field != "outputs" {
+					t.Fatalf("collectOutputs() error = %v, want %s on outputs", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collectOutputs() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
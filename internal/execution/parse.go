@@ -0,0 +1,223 @@
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Output formats for parsing stdout into the "parsed" output field.
+const (
+	OutputFormatText  = "text"
+	OutputFormatJSON  = "json"
+	OutputFormatYAML  = "yaml"
+	OutputFormatLines = "lines"
+)
+
+// extractor evaluates an extract expression against stdout. Expressions that
+// start with "$" are JSONPath; anything else is a regular expression.
+type extractor struct {
+	re   *regexp.Regexp
+	path *jsonpath.JSONPath
+}
+
+func compileExtractor(name, expr string) (*extractor, error) {
+	if strings.HasPrefix(expr, "$") {
+		jp := jsonpath.New(name)
+		if err := jp.Parse("{" + expr[1:] + "}"); err != nil {
+			return nil, fmt.Errorf("invalid JSONPath: %w", err)
+		}
+		return &extractor{path: jp}, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	return &extractor{re: re}, nil
+}
+
+// extract returns the value an expression selects. A regex yields its named
+// groups as an object, its single group, or the whole match. A JSONPath yields
+// its single result, or a list when it matches several.
+func (e *extractor) extract(stdout string, document func() (interface{}, error)) (interface{}, error) {
+	if e.re != nil {
+		m := e.re.FindStringSubmatch(stdout)
+		if m == nil {
+			return nil, fmt.Errorf("no match in stdout")
+		}
+		names := e.re.SubexpNames()
+		named := map[string]interface{}{}
+		for i, n := range names {
+			if n != "" {
+				named[n] = m[i]
+			}
+		}
+		switch {
+		case len(named) > 0:
+			return named, nil
+		case len(m) > 1:
+			return m[1], nil
+		}
+		return m[0], nil
+	}
+
+	doc, err := document()
+	if err != nil {
+		return nil, err
+	}
+	results, err := e.path.FindResults(doc)
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	for _, set := range results {
+		for _, v := range set {
+			values = append(values, v.Interface())
+		}
+	}
+	switch len(values) {
+	case 0:
+		return nil, fmt.Errorf("no match in stdout")
+	case 1:
+		return values[0], nil
+	}
+	return values, nil
+}
+
+// parseOutput parses stdout according to the output format and evaluates the
+// extract expressions. Every failure is reported as a field violation.
+func parseOutput(ctx *Context, stdout string) (parsed interface{}, extracted map[string]interface{}, violations []*executorv1.FieldViolation) {
+	var parseErr error
+	switch ctx.OutputFormat {
+	case OutputFormatJSON:
+		parsed, parseErr = parseJSON(stdout)
+	case OutputFormatYAML:
+		parsed, parseErr = parseYAML(stdout)
+	case OutputFormatLines:
+		lines := []interface{}{}
+		for _, l := range strings.Split(strings.TrimRight(stdout, "\n"), "\n") {
+			if l != "" {
+				lines = append(lines, l)
+			}
+		}
+		parsed = lines
+	}
+	if parseErr != nil {
+		violations = append(violations, &executorv1.FieldViolation{
+			Field:       "output_format",
+			Description: fmt.Sprintf("stdout is not valid %s: %v", ctx.OutputFormat, parseErr),
+		})
+	}
+
+	// JSONPath expressions run against the parsed document, or stdout as JSON.
+	document := func() (interface{}, error) {
+		if parsed != nil && (ctx.OutputFormat == OutputFormatJSON || ctx.OutputFormat == OutputFormatYAML) {
+			return parsed, nil
+		}
+		doc, err := parseJSON(stdout)
+		if err != nil {
+			return nil, fmt.Errorf("stdout is not valid JSON: %w", err)
+		}
+		return doc, nil
+	}
+
+	names := make([]string, 0, len(ctx.Extract))
+	for name := range ctx.Extract {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, err := evalExtract(name, ctx.Extract[name], stdout, document)
+		if err != nil {
+			violations = append(violations, &executorv1.FieldViolation{
+				Field:       "extract." + name,
+				Description: err.Error(),
+			})
+			continue
+		}
+		if extracted == nil {
+			extracted = map[string]interface{}{}
+		}
+		extracted[name] = value
+	}
+	return parsed, extracted, violations
+}
+
+func evalExtract(name, expr, stdout string, document func() (interface{}, error)) (interface{}, error) {
+	e, err := compileExtractor(name, expr)
+	if err != nil {
+		return nil, err
+	}
+	return e.extract(stdout, document)
+}
+
+func parseJSON(s string) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func parseYAML(s string) (interface{}, error) {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return nil, err
+	}
+	return normalizeYAML(v)
+}
+
+// normalizeYAML converts a decoded YAML document into the JSON data model, so
+// it can be stored in a Struct and queried with JSONPath.
+func normalizeYAML(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			n, err := normalizeYAML(val)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = n
+		}
+		return out, nil
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			n, err := normalizeYAML(val)
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(k)] = n
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			n, err := normalizeYAML(val)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = n
+		}
+		return out, nil
+	case int:
+		return float64(t), nil
+	case int64:
+		return float64(t), nil
+	case uint64:
+		return float64(t), nil
+	case time.Time:
+		return t.Format(time.RFC3339), nil
+	case nil, string, bool, float64:
+		return t, nil
+	}
+	return nil, fmt.Errorf("unsupported YAML value of type %T", v)
+}
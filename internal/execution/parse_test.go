@@ -0,0 +1,146 @@
+package execution
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		extract       map[string]string
+		stdout        string
+		wantParsed    interface{}
+		wantExtracted map[string]interface{}
+		wantFields    []string
+	}{
+		{name: "text", format: OutputFormatText, stdout: "hello\n"},
+		{
+			name:       "json",
+			format:     OutputFormatJSON,
+			stdout:     `{"a": [1, "x"]}`,
+			wantParsed: map[string]interface{}{"a": []interface{}{float64(1), "x"}},
+		},
+		{name: "invalid json", format: OutputFormatJSON, stdout: "not json", wantFields: []string{"output_format"}},
+		{
+			name:   "yaml ints, times and non-string keys",
+			format: OutputFormatYAML,
+			stdout: "count: 3\nbig: 18446744073709551615\nat: 2024-01-02T03:04:05Z\nbyport:\n  80: http\nok: true\nratio: 0.5\nnone: null\n",
+			wantParsed: map[string]interface{}{
+				"count":  float64(3),
+				"big":    float64(18446744073709551615),
+				"at":     "2024-01-02T03:04:05Z",
+				"byport": map[string]interface{}{"80": "http"},
+				"ok":     true,
+				"ratio":  0.5,
+				"none":   nil,
+			},
+		},
+		{name: "invalid yaml", format: OutputFormatYAML, stdout: "a: [", wantFields: []string{"output_format"}},
+		{
+			name:       "lines",
+			format:     OutputFormatLines,
+			stdout:     "a\n\nb\n",
+			wantParsed: []interface{}{"a", "b"},
+		},
+		{
+			name:   "regex extraction",
+			stdout: "version 1.2.3 built by ci\n",
+			extract: map[string]string{
+				"whole":  `\d+\.\d+\.\d+`,
+				"group":  `built by (\w+)`,
+				"named":  `version (?P<major>\d+)\.(?P<minor>\d+)`,
+				"absent": `nope`,
+				"broken": `(`,
+			},
+			wantExtracted: map[string]interface{}{
+				"whole": "1.2.3",
+				"group": "ci",
+				"named": map[string]interface{}{"major": "1", "minor": "2"},
+			},
+			wantFields: []string{"extract.absent", "extract.broken"},
+		},
+		{
+			name:   "JSONPath against stdout as JSON",
+			stdout: `{"items": [{"name": "a"}, {"name": "b"}], "count": 2}`,
+			extract: map[string]string{
+				"count":  "$.count",
+				"names":  "$.items[*].name",
+				"first":  "$.items[0].name",
+				"absent": "$.items[?(@.name==\"c\")].name",
+			},
+			wantExtracted: map[string]interface{}{
+				"count": float64(2),
+				"names": []interface{}{"a", "b"},
+				"first": "a",
+			},
+			wantFields: []string{"extract.absent"},
+		},
+		{
+			name:          "JSONPath against parsed yaml",
+			format:        OutputFormatYAML,
+			stdout:        "replicas: 3\n",
+			extract:       map[string]string{"replicas": "$.replicas"},
+			wantParsed:    map[string]interface{}{"replicas": float64(3)},
+			wantExtracted: map[string]interface{}{"replicas": float64(3)},
+		},
+		{
+			name:       "JSONPath against stdout that is not JSON",
+			stdout:     "plain text",
+			extract:    map[string]string{"x": "$.x"},
+			wantFields: []string{"extract.x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, extracted, violations := parseOutput(&Context{OutputFormat: tt.format, Extract: tt.extract}, tt.stdout)
+			if !reflect.DeepEqual(parsed, tt.wantParsed) {
+				t.Errorf("parsed = %#v, want %#v", parsed, tt.wantParsed)
+			}
+			if !reflect.DeepEqual(extracted, tt.wantExtracted) {
+				t.Errorf("extracted = %#v, want %#v", extracted, tt.wantExtracted)
+			}
+			var fields []string
+			for _, v := range violations {
+				fields = append(fields, v.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("violations on %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestNormalizeYAML(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		in      interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "int", in: 3, want: float64(3)},
+		{name: "int64", in: int64(-4), want: float64(-4)},
+		{name: "uint64", in: uint64(5), want: float64(5)},
+		{name: "time", in: at, want: "2024-01-02T03:04:05Z"},
+		{
+			name: "nested",
+			in:   map[interface{}]interface{}{1: []interface{}{2, "x"}, "t": at},
+			want: map[string]interface{}{"1": []interface{}{float64(2), "x"}, "t": "2024-01-02T03:04:05Z"},
+		},
+		{name: "unsupported", in: map[string]interface{}{"a": []byte("x")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeYAML(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeYAML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeYAML() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
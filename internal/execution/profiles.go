@@ -0,0 +1,240 @@
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rakeshavasarala/script-executor/internal/config"
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// reservedLabels identify the executor's Jobs and pods; neither request
+// labels nor profiles may change them.
+var reservedLabels = []string{"executor", "execution-id", "runbook-id", "user", "managed-by", workspaceLabel}
+
+// reservedAnnotations describe what a Job runs, and are read back when a
+// retried request reattaches to it; neither request annotations nor profiles
+// may change them.
+var reservedAnnotations = []string{"script-hash", "execution-id", "runbook-id", "user", "image", "created-by"}
+
+// applyProfile patches a generated Job with a job profile, then checks that
+// the patch only changed allowed paths and did not loosen the hardened
+// security settings.
+func applyProfile(job *batchv1.Job, name string, cfg config.JobProfilesConfig) (*batchv1.Job, error) {
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown job profile %q", name)
+	}
+	original, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("encode job: %w", err)
+	}
+
+	patched := original
+	if len(profile.StrategicMerge) > 0 {
+		patch, err := json.Marshal(profile.StrategicMerge)
+		if err != nil {
+			return nil, fmt.Errorf("job profile %s: encode strategic merge patch: %w", name, err)
+		}
+		if patched, err = strategicpatch.StrategicMergePatch(patched, patch, batchv1.Job{}); err != nil {
+			return nil, fmt.Errorf("job profile %s: strategic merge patch: %w", name, err)
+		}
+	}
+	if len(profile.JSONPatch) > 0 {
+		ops, err := json.Marshal(profile.JSONPatch)
+		if err != nil {
+			return nil, fmt.Errorf("job profile %s: encode json patch: %w", name, err)
+		}
+		patch, err := jsonpatch.DecodePatch(ops)
+		if err != nil {
+			return nil, fmt.Errorf("job profile %s: json patch: %w", name, err)
+		}
+		if patched, err = patch.Apply(patched); err != nil {
+			return nil, fmt.Errorf("job profile %s: json patch: %w", name, err)
+		}
+	}
+
+	var before, after interface{}
+	if err := json.Unmarshal(original, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return nil, err
+	}
+	var changed []string
+	changedPaths(before, after, "", &changed)
+	for _, p := range changed {
+		if !pathAllowed(p, cfg.AllowedPaths) {
+			return nil, fmt.Errorf("job profile %s changes %s, which is not an allowed path", name, p)
+		}
+	}
+
+	out := &batchv1.Job{}
+	if err := json.Unmarshal(patched, out); err != nil {
+		return nil, fmt.Errorf("job profile %s: decode patched job: %w", name, err)
+	}
+	if reasons := loosenedSecurity(job, out); len(reasons) > 0 {
+		return nil, fmt.Errorf("job profile %s loosens security: %s", name, strings.Join(reasons, "; "))
+	}
+	return out, nil
+}
+
+// changedPaths appends the dotted paths at which a and b, decoded JSON
+// values, differ. List items are compared in place and share their list's
+// path; a list whose length changed is reported as a whole.
+func changedPaths(a, b interface{}, path string, out *[]string) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	am, aok := a.(map[string]interface{})
+	bm, bok := b.(map[string]interface{})
+	if aok && bok {
+		keys := make(map[string]bool, len(am)+len(bm))
+		for k := range am {
+			keys[k] = true
+		}
+		for k := range bm {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			changedPaths(am[k], bm[k], join(k), out)
+		}
+		return
+	}
+	al, aok := a.([]interface{})
+	bl, bok := b.([]interface{})
+	if aok && bok && len(al) == len(bl) {
+		for i := range al {
+			changedPaths(al[i], bl[i], path, out)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*out = append(*out, path)
+	}
+}
+
+func pathAllowed(p string, allowed []string) bool {
+	for _, a := range allowed {
+		if p == a || strings.HasPrefix(p, a+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// loosenedSecurity returns how the patched Job is less restricted than the
+// generated one. Every container of the patched pod, including any a profile
+// adds, must be at least as restricted as the generated script container.
+func loosenedSecurity(orig, patched *batchv1.Job) []string {
+	var reasons []string
+	for _, key := range reservedLabels {
+		if orig.Labels[key] != patched.Labels[key] {
+			reasons = append(reasons, fmt.Sprintf("changes job label %s", key))
+		}
+		if orig.Spec.Template.Labels[key] != patched.Spec.Template.Labels[key] {
+			reasons = append(reasons, fmt.Sprintf("changes pod label %s", key))
+		}
+	}
+	for _, key := range reservedAnnotations {
+		if orig.Annotations[key] != patched.Annotations[key] {
+			reasons = append(reasons, fmt.Sprintf("changes job annotation %s", key))
+		}
+	}
+
+	op, pp := &orig.Spec.Template.Spec, &patched.Spec.Template.Spec
+	if pp.HostNetwork || pp.HostPID || pp.HostIPC {
+		reasons = append(reasons, "uses host namespaces")
+	}
+	for _, v := range pp.Volumes {
+		if v.HostPath != nil {
+			reasons = append(reasons, fmt.Sprintf("mounts host path in volume %s", v.Name))
+		}
+	}
+	if osc := op.SecurityContext; osc != nil {
+		psc := pp.SecurityContext
+		if psc == nil {
+			psc = &corev1.PodSecurityContext{}
+		}
+		if isTrue(osc.RunAsNonRoot) && !isTrue(psc.RunAsNonRoot) {
+			reasons = append(reasons, "pod runAsNonRoot is no longer true")
+		}
+		if osc.RunAsUser != nil && *osc.RunAsUser != 0 && (psc.RunAsUser == nil || *psc.RunAsUser == 0) {
+			reasons = append(reasons, "pod runAsUser is root or unset")
+		}
+		if osc.SeccompProfile != nil && (psc.SeccompProfile == nil || psc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined) {
+			reasons = append(reasons, "pod seccomp profile is unconfined")
+		}
+	}
+
+	if len(op.Containers) == 0 || op.Containers[0].SecurityContext == nil {
+		return reasons
+	}
+	base := op.Containers[0].SecurityContext
+	containers := append(append([]corev1.Container{}, pp.InitContainers...), pp.Containers...)
+	for _, c := range containers {
+		sc := c.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+		loosened := func(what string) {
+			reasons = append(reasons, fmt.Sprintf("container %s %s", c.Name, what))
+		}
+		if isTrue(sc.Privileged) {
+			loosened("is privileged")
+		}
+		if base.AllowPrivilegeEscalation != nil && !*base.AllowPrivilegeEscalation && (sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation) {
+			loosened("allows privilege escalation")
+		}
+		if isTrue(base.ReadOnlyRootFilesystem) && !isTrue(sc.ReadOnlyRootFilesystem) {
+			loosened("has a writable root filesystem")
+		}
+		if isTrue(base.RunAsNonRoot) && sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot {
+			loosened("may run as root")
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			loosened("runs as root")
+		}
+		if sc.Capabilities != nil && len(sc.Capabilities.Add) > 0 {
+			loosened("adds capabilities")
+		}
+		if base.Capabilities != nil && containsCapability(base.Capabilities.Drop, "ALL") &&
+			(sc.Capabilities == nil || !containsCapability(sc.Capabilities.Drop, "ALL")) {
+			loosened("does not drop all capabilities")
+		}
+		if sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			loosened("has an unconfined seccomp profile")
+		}
+		if sc.ProcMount != nil && *sc.ProcMount == corev1.UnmaskedProcMount {
+			loosened("has an unmasked /proc")
+		}
+	}
+	return reasons
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+func containsCapability(caps []corev1.Capability, c corev1.Capability) bool {
+	for _, v := range caps {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
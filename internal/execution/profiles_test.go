@@ -0,0 +1,268 @@
+package execution
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rakeshavasarala/script-executor/internal/config"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+// testJob builds the Job of a minimal inline script.
+func testJob(t *testing.T, cfg *config.Config) *batchv1.Job {
+	t.Helper()
+	job, err := NewJobBuilder(cfg).Build(&Context{
+		ExecutionID: "exec-1",
+		RunbookID:   "rb-1",
+		User:        "alice",
+		Script:      "echo hi",
+		ScriptHash:  "abc",
+		Image:       "busybox:1.36",
+		Interpreter: "/bin/sh",
+		WorkingDir:  "/workspace",
+		Labels:      map[string]string{},
+		Annotations: map[string]string{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func TestChangedPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b interface{}
+		want []string
+	}{
+		{
+			name: "equal",
+			a:    map[string]interface{}{"x": 1.0, "y": []interface{}{"a"}},
+			b:    map[string]interface{}{"x": 1.0, "y": []interface{}{"a"}},
+		},
+		{
+			name: "nested value",
+			a:    map[string]interface{}{"spec": map[string]interface{}{"dnsPolicy": "ClusterFirst"}},
+			b:    map[string]interface{}{"spec": map[string]interface{}{"dnsPolicy": "None"}},
+			want: []string{"spec.dnsPolicy"},
+		},
+		{
+			name: "added and removed keys",
+			a:    map[string]interface{}{"a": 1.0},
+			b:    map[string]interface{}{"b": 1.0},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "list items share the list path",
+			a:    map[string]interface{}{"containers": []interface{}{map[string]interface{}{"image": "a"}}},
+			b:    map[string]interface{}{"containers": []interface{}{map[string]interface{}{"image": "b"}}},
+			want: []string{"containers.image"},
+		},
+		{
+			name: "list length change is reported as a whole",
+			a:    map[string]interface{}{"volumes": []interface{}{"a"}},
+			b:    map[string]interface{}{"volumes": []interface{}{"a", "b"}},
+			want: []string{"volumes"},
+		},
+		{
+			name: "type change",
+			a:    map[string]interface{}{"x": map[string]interface{}{"y": 1.0}},
+			b:    map[string]interface{}{"x": "y"},
+			want: []string{"x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			changedPaths(tt.a, tt.b, "", &got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changedPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathAllowed(t *testing.T) {
+	allowed := []string{"metadata.annotations", "spec.template.spec.dnsConfig"}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"metadata.annotations", true},
+		{"metadata.annotations.vault", true},
+		{"spec.template.spec.dnsConfig.nameservers", true},
+		{"metadata.annotationsX", false},
+		{"spec.template.spec.dns", false},
+		{"spec.template.spec", false},
+	}
+	for _, tt := range tests {
+		if got := pathAllowed(tt.path, allowed); got != tt.want {
+			t.Errorf("pathAllowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoosenedSecurity(t *testing.T) {
+	cfg := testConfig(t)
+	tests := []struct {
+		name   string
+		mutate func(*batchv1.Job)
+		want   string
+	}{
+		{name: "unchanged", mutate: func(*batchv1.Job) {}},
+		{
+			name:   "job label",
+			mutate: func(j *batchv1.Job) { j.Labels["execution-id"] = "other" },
+			want:   "changes job label execution-id",
+		},
+		{
+			name:   "pod label",
+			mutate: func(j *batchv1.Job) { j.Spec.Template.Labels["executor"] = "other" },
+			want:   "changes pod label executor",
+		},
+		{
+			name:   "reserved annotation",
+			mutate: func(j *batchv1.Job) { j.Annotations["script-hash"] = "forged" },
+			want:   "changes job annotation script-hash",
+		},
+		{
+			name:   "host network",
+			mutate: func(j *batchv1.Job) { j.Spec.Template.Spec.HostNetwork = true },
+			want:   "uses host namespaces",
+		},
+		{
+			name: "host path",
+			mutate: func(j *batchv1.Job) {
+				j.Spec.Template.Spec.Volumes = append(j.Spec.Template.Spec.Volumes, corev1.Volume{
+					Name:         "host",
+					VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}},
+				})
+			},
+			want: "mounts host path in volume host",
+		},
+		{
+			name:   "pod runs as root",
+			mutate: func(j *batchv1.Job) { j.Spec.Template.Spec.SecurityContext.RunAsUser = ptr.To(int64(0)) },
+			want:   "pod runAsUser is root or unset",
+		},
+		{
+			name:   "pod security context removed",
+			mutate: func(j *batchv1.Job) { j.Spec.Template.Spec.SecurityContext = nil },
+			want:   "pod runAsNonRoot is no longer true",
+		},
+		{
+			name:   "privileged container",
+			mutate: func(j *batchv1.Job) { j.Spec.Template.Spec.Containers[0].SecurityContext.Privileged = ptr.To(true) },
+			want:   "container script is privileged",
+		},
+		{
+			name: "privilege escalation",
+			mutate: func(j *batchv1.Job) {
+				j.Spec.Template.Spec.Containers[0].SecurityContext.AllowPrivilegeEscalation = nil
+			},
+			want: "container script allows privilege escalation",
+		},
+		{
+			name: "added capability",
+			mutate: func(j *batchv1.Job) {
+				j.Spec.Template.Spec.Containers[0].SecurityContext.Capabilities.Add = []corev1.Capability{"NET_ADMIN"}
+			},
+			want: "container script adds capabilities",
+		},
+		{
+			name: "added container without a security context",
+			mutate: func(j *batchv1.Job) {
+				j.Spec.Template.Spec.InitContainers = append(j.Spec.Template.Spec.InitContainers, corev1.Container{Name: "extra"})
+			},
+			want: "container extra allows privilege escalation",
+		},
+		{
+			name: "unconfined seccomp",
+			mutate: func(j *batchv1.Job) {
+				j.Spec.Template.Spec.Containers[0].SecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}
+			},
+			want: "container script has an unconfined seccomp profile",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := testJob(t, cfg)
+			patched := orig.DeepCopy()
+			tt.mutate(patched)
+			reasons := loosenedSecurity(orig, patched)
+			if tt.want == "" {
+				if len(reasons) > 0 {
+					t.Errorf("loosenedSecurity() = %v, want none", reasons)
+				}
+				return
+			}
+			found := false
+			for _, r := range reasons {
+				found = found || r == tt.want
+			}
+			if !found {
+				t.Errorf("loosenedSecurity() = %v, want %q among them", reasons, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	cfg := testConfig(t)
+	profiles := config.JobProfilesConfig{
+		AllowedPaths: []string{"spec.template.metadata.annotations", "spec.template.spec.dnsPolicy", "spec.template.spec.containers"},
+		Profiles: map[string]config.JobProfile{
+			"vault": {StrategicMerge: map[string]interface{}{
+				"spec": map[string]interface{}{"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"annotations": map[string]interface{}{"vault.hashicorp.com/agent-inject": "true"}},
+				}},
+			}},
+			"dns": {JSONPatch: []map[string]interface{}{
+				{"op": "add", "path": "/spec/template/spec/dnsPolicy", "value": "None"},
+			}},
+			"backoff": {JSONPatch: []map[string]interface{}{
+				{"op": "replace", "path": "/spec/backoffLimit", "value": 6},
+			}},
+			"privileged": {JSONPatch: []map[string]interface{}{
+				{"op": "add", "path": "/spec/template/spec/containers/0/securityContext/privileged", "value": true},
+			}},
+		},
+	}
+	tests := []struct {
+		profile string
+		wantErr string
+	}{
+		{profile: "vault"},
+		{profile: "dns"},
+		{profile: "backoff", wantErr: "changes spec.backoffLimit, which is not an allowed path"},
+		{profile: "privileged", wantErr: "container script is privileged"},
+		{profile: "missing", wantErr: "unknown job profile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			job, err := applyProfile(testJob(t, cfg), tt.profile, profiles)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyProfile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch tt.profile {
+			case "vault":
+				if job.Spec.Template.Annotations["vault.hashicorp.com/agent-inject"] != "true" {
+					t.Errorf("annotation not applied: %v", job.Spec.Template.Annotations)
+				}
+			case "dns":
+				if job.Spec.Template.Spec.DNSPolicy != corev1.DNSNone {
+					t.Errorf("dnsPolicy = %q, want None", job.Spec.Template.Spec.DNSPolicy)
+				}
+			}
+		})
+	}
+}
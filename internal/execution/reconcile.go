@@ -0,0 +1,152 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/record"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reconcileGrace is how long a finished Job is left to the executor monitoring
+// it before any replica records its result from the Job instead.
+const reconcileGrace = 2 * time.Minute
+
+// encodeOutputParams returns the output parameters of a request, for the
+// execution record.
+func encodeOutputParams(params *structpb.Struct) json.RawMessage {
+	fields := map[string]*structpb.Value{}
+	for _, name := range outputParamNames {
+		if v, ok := params.GetFields()[name]; ok {
+			fields[name] = v
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	raw, err := protojson.Marshal(&structpb.Struct{Fields: fields})
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// reconcileRecords finishes the running executions whose monitor went away:
+// the executor restarted, or the one that launched them is gone.
+func (m *Manager) reconcileRecords(ctx context.Context) {
+	records, err := m.records.List(ctx, record.StateRunning)
+	if err != nil {
+		log.Printf("list running executions: %v", err)
+		return
+	}
+	for _, rec := range records {
+		m.reconcile(ctx, rec)
+	}
+}
+
+// reconcile records the result of a running execution from its Job, once the
+// Job finished more than reconcileGrace ago, or is gone. The result is checked
+// against the request's output parameters like any other; the audit log entry
+// lacks what only the request had (stdin, files, inputs). It returns the
+// record as it is afterwards.
+func (m *Manager) reconcile(ctx context.Context, rec *record.Record) *record.Record {
+	if rec.State != record.StateRunning || rec.JobName == "" {
+		return rec
+	}
+	job, err := m.client.BatchV1().Jobs(m.config.ScriptExecutor.Kubernetes.Namespace).Get(ctx, rec.JobName, metav1.GetOptions{})
+	var resp *executorv1.ExecuteResponse
+	var result *Result
+	execContext := &Context{
+		ExecutionID: rec.ExecutionID,
+		RunbookID:   rec.RunbookID,
+		User:        rec.User,
+		ScriptHash:  rec.ScriptHash,
+		Image:       rec.Image,
+	}
+	switch {
+	case apierrors.IsNotFound(err):
+		if time.Since(rec.CreatedAt) < reconcileGrace {
+			return rec
+		}
+		// The Job's TTL removed it, and the result with it
+		resp = errorResponse(&DetailedError{
+			Code:     CodeResultLost,
+			Message:  fmt.Sprintf("job %s is gone and its result was never collected", rec.JobName),
+			Metadata: map[string]string{"job_name": rec.JobName},
+		}, rec.CreatedAt)
+		result = &Result{JobName: rec.JobName, ExitCode: -1, Duration: time.Since(rec.CreatedAt)}
+	case err != nil:
+		log.Printf("get job %s of execution %s: %v", rec.JobName, rec.ExecutionID, err)
+		return rec
+	default:
+		finishedAt, ok := jobFinishedAt(job)
+		if !ok || time.Since(finishedAt) < reconcileGrace {
+			return rec
+		}
+		if result, err = m.monitor.collectResult(ctx, job); err != nil {
+			log.Printf("collect result of job %s: %v", job.Name, err)
+			return rec
+		}
+		params := &structpb.Struct{}
+		if len(rec.OutputParams) > 0 {
+			if err := protojson.Unmarshal(rec.OutputParams, params); err != nil {
+				log.Printf("read output parameters of execution %s: %v", rec.ExecutionID, err)
+			}
+		}
+		if err := parseOutputParams(execContext, params); err != nil {
+			// They were valid when the execution started
+			log.Printf("output parameters of execution %s: %v", rec.ExecutionID, err)
+		}
+		resp = m.respond(ctx, execContext, result)
+	}
+
+	var finished *record.Record
+	updated, err := record.Update(ctx, m.records, rec.ExecutionID, func(r *record.Record) bool {
+		finished = nil
+		if r.State != record.StateRunning {
+			// Its monitor, or another replica, finished it meanwhile
+			return false
+		}
+		r.State = stateOf(resp)
+		r.FinishedAt = time.Now()
+		if result.PodName != "" {
+			r.PodName = result.PodName
+		}
+		if raw, err := protojson.Marshal(resp); err == nil {
+			r.Result = raw
+		}
+		finished = r
+		return true
+	})
+	if err != nil {
+		log.Printf("save execution record %s: %v", rec.ExecutionID, err)
+		return rec
+	}
+	if finished != nil {
+		log.Printf("Recorded the result of execution %s from job %s: %s", rec.ExecutionID, rec.JobName, finished.State)
+		m.auditExecution(execContext, result, resp)
+	}
+	return updated
+}
+
+// jobFinishedAt returns when a Job completed or failed.
+func jobFinishedAt(job *batchv1.Job) (time.Time, bool) {
+	if job.Status.CompletionTime != nil {
+		return job.Status.CompletionTime.Time, true
+	}
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return c.LastTransitionTime.Time, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,111 @@
+package execution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/record"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReconcileRecords(t *testing.T) {
+	cfg := testConfig(t)
+	namespace := cfg.ScriptExecutor.Kubernetes.Namespace
+	job := func(name string, finished time.Duration, condition batchv1.JobConditionType) *batchv1.Job {
+		j := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		if condition != "" {
+			at := metav1.NewTime(time.Now().Add(-finished))
+			j.Status.Conditions = []batchv1.JobCondition{{Type: condition, Status: corev1.ConditionTrue, LastTransitionTime: at}}
+			if condition == batchv1.JobComplete {
+				j.Status.Succeeded = 1
+				j.Status.CompletionTime = &at
+			} else {
+				j.Status.Failed = 1
+			}
+		}
+		return j
+	}
+	client := fake.NewSimpleClientset(
+		job("job-succeeded", 10*time.Minute, batchv1.JobComplete),
+		job("job-failed", 10*time.Minute, batchv1.JobFailed),
+		job("job-criteria", 10*time.Minute, batchv1.JobComplete),
+		job("job-recent", 10*time.Second, batchv1.JobComplete),
+		job("job-running", 0, ""),
+	)
+	m := &Manager{
+		config:  cfg,
+		client:  client,
+		monitor: NewMonitor(client, namespace, captureLimits(cfg), nil),
+		records: record.NewConfigMapStore(client, namespace, "rec"),
+	}
+	ctx := context.Background()
+	criteria := encodeOutputParams(mustStruct(t, map[string]interface{}{
+		"success_criteria": map[string]interface{}{"stdout_matches": []interface{}{"OK"}},
+	}))
+	old := time.Now().Add(-time.Hour)
+	for _, rec := range []*record.Record{
+		{ExecutionID: "succeeded", JobName: "job-succeeded", CreatedAt: old},
+		{ExecutionID: "failed", JobName: "job-failed", CreatedAt: old},
+		{ExecutionID: "criteria", JobName: "job-criteria", OutputParams: criteria, CreatedAt: old},
+		{ExecutionID: "recent", JobName: "job-recent", CreatedAt: old},
+		{ExecutionID: "running", JobName: "job-running", CreatedAt: old},
+		{ExecutionID: "lost", JobName: "job-lost", CreatedAt: old},
+		{ExecutionID: "just-launched", JobName: "job-just-launched", CreatedAt: time.Now()},
+	} {
+		rec.State = record.StateRunning
+		if err := m.records.Save(ctx, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.reconcileRecords(ctx)
+
+	tests := []struct {
+		executionID string
+		state       record.State
+		code        string
+	}{
+		{"succeeded", record.StateSucceeded, ""},
+		{"failed", record.StateFailed, ""},
+		{"criteria", record.StateFailed, CodeCriteriaNotMet},
+		{"recent", record.StateRunning, ""},
+		{"running", record.StateRunning, ""},
+		{"lost", record.StateFailed, CodeResultLost},
+		{"just-launched", record.StateRunning, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.executionID, func(t *testing.T) {
+			exec, err := m.GetExecution(ctx, tt.executionID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec, err := m.records.Get(ctx, tt.executionID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rec.State != tt.state {
+				t.Errorf("state = %s, want %s", rec.State, tt.state)
+			}
+			if !rec.State.Terminal() {
+				if exec.Result != nil {
+					t.Errorf("running execution has a result: %v", exec.Result)
+				}
+				return
+			}
+			if exec.Result == nil || exec.FinishedAt == nil {
+				t.Fatalf("finished execution = %v, want a result", exec)
+			}
+			if got := exec.Result.GetErrorDetails().GetCode(); got != tt.code {
+				t.Errorf("code = %q, want %q", got, tt.code)
+			}
+			if tt.state == record.StateSucceeded && exec.Result.Status != executorv1.ExecuteResponse_STATUS_SUCCEEDED {
+				t.Errorf("status = %s", exec.Result.Status)
+			}
+		})
+	}
+}
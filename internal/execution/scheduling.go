@@ -0,0 +1,421 @@
+package execution
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/utils/ptr"
+)
+
+// defaultTopologyKey is used by pod affinity terms that do not name one.
+const defaultTopologyKey = "kubernetes.io/hostname"
+
+// schedulingParser collects field violations while reading the tolerations,
+// affinity and topology_spread_constraints parameters.
+type schedulingParser struct {
+	policy     config.TolerationPolicyConfig
+	violations []*executorv1.FieldViolation
+}
+
+func (p *schedulingParser) violation(field, desc string) {
+	p.violations = append(p.violations, &executorv1.FieldViolation{Field: field, Description: desc})
+}
+
+// parseScheduling reads the scheduling parameters:
+//
+//	tolerations: [{key, operator, value, effect, toleration_seconds}]
+//	affinity: {node_affinity, pod_affinity, pod_anti_affinity}, each with
+//	  required and preferred terms
+//	topology_spread_constraints: [{max_skew, topology_key,
+//	  when_unsatisfiable, label_selector, min_domains, match_label_keys}]
+func parseScheduling(params *structpb.Struct, policy config.TolerationPolicyConfig) ([]corev1.Toleration, *corev1.Affinity, []corev1.TopologySpreadConstraint, []*executorv1.FieldViolation) {
+	p := &schedulingParser{policy: policy}
+	tolerations := p.tolerations(getList(params, "tolerations"))
+	var affinity *corev1.Affinity
+	if a := getMap(params, "affinity"); a != nil {
+		affinity = p.affinity(a)
+	}
+	spread := p.topologySpread(getList(params, "topology_spread_constraints"))
+	return tolerations, affinity, spread, p.violations
+}
+
+func (p *schedulingParser) tolerations(list []*structpb.Value) []corev1.Toleration {
+	var out []corev1.Toleration
+	for i, v := range list {
+		field := fmt.Sprintf("tolerations[%d]", i)
+		s := v.GetStructValue()
+		if s == nil {
+			p.violation(field, "must be an object")
+			continue
+		}
+		t := corev1.Toleration{
+			Key:      getString(s, "key", ""),
+			Operator: corev1.TolerationOperator(getString(s, "operator", string(corev1.TolerationOpEqual))),
+			Value:    getString(s, "value", ""),
+			Effect:   corev1.TaintEffect(getString(s, "effect", "")),
+		}
+		ok := true
+		switch t.Operator {
+		case corev1.TolerationOpEqual:
+			if t.Key == "" {
+				p.violation(field+".key", "is required with operator Equal")
+				ok = false
+			}
+		case corev1.TolerationOpExists:
+			if t.Value != "" {
+				p.violation(field+".value", "must be empty with operator Exists")
+				ok = false
+			}
+		default:
+			p.violation(field+".operator", "must be Equal or Exists")
+			ok = false
+		}
+		if t.Key != "" {
+			if errs := validation.IsQualifiedName(t.Key); len(errs) > 0 {
+				p.violation(field+".key", strings.Join(errs, "; "))
+				ok = false
+			}
+		}
+		switch t.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			p.violation(field+".effect", "must be NoSchedule, PreferNoSchedule or NoExecute")
+			ok = false
+		}
+		if sv, set := s.Fields["toleration_seconds"]; set {
+			if t.Effect != corev1.TaintEffectNoExecute {
+				p.violation(field+".toleration_seconds", "requires effect NoExecute")
+				ok = false
+			} else {
+				t.TolerationSeconds = ptr.To(int64(sv.GetNumberValue()))
+			}
+		}
+		if desc := p.tolerationDenied(t.Key); desc != "" {
+			p.violation(field+".key", desc)
+			ok = false
+		}
+		if ok {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// tolerationDenied returns why the policy forbids tolerating key, or "".
+func (p *schedulingParser) tolerationDenied(key string) string {
+	if key == "" {
+		if len(p.policy.AllowedKeys) > 0 || len(p.policy.DeniedKeys) > 0 {
+			return "tolerating every taint is not allowed"
+		}
+		return ""
+	}
+	for _, denied := range p.policy.DeniedKeys {
+		if matchTaintKey(denied, key) {
+			return fmt.Sprintf("tolerating %s is not allowed", key)
+		}
+	}
+	if len(p.policy.AllowedKeys) == 0 {
+		return ""
+	}
+	for _, allowed := range p.policy.AllowedKeys {
+		if matchTaintKey(allowed, key) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s is not an allowed taint key", key)
+}
+
+func matchTaintKey(pattern, key string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(key, prefix)
+	}
+	return pattern == key
+}
+
+func (p *schedulingParser) affinity(a *structpb.Struct) *corev1.Affinity {
+	out := &corev1.Affinity{}
+	for _, key := range sortedKeys(a.Fields) {
+		switch key {
+		case "node_affinity", "pod_affinity", "pod_anti_affinity":
+		default:
+			p.violation("affinity."+key, "unknown affinity (node_affinity, pod_affinity, pod_anti_affinity)")
+		}
+	}
+	if na := getMap(a, "node_affinity"); na != nil {
+		field := "affinity.node_affinity"
+		out.NodeAffinity = &corev1.NodeAffinity{}
+		if required := listOrOne(na.Fields["required"]); len(required) > 0 {
+			terms := &corev1.NodeSelector{}
+			for i, v := range required {
+				f := fmt.Sprintf("%s.required[%d]", field, i)
+				if s := p.object(v, f); s != nil {
+					terms.NodeSelectorTerms = append(terms.NodeSelectorTerms, p.nodeSelectorTerm(s, f))
+				}
+			}
+			out.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = terms
+		}
+		for i, v := range listOrOne(na.Fields["preferred"]) {
+			f := fmt.Sprintf("%s.preferred[%d]", field, i)
+			s := p.object(v, f)
+			if s == nil {
+				continue
+			}
+			// The preference may be nested or given inline next to the weight
+			term := s
+			if pref := getMap(s, "preference"); pref != nil {
+				term = pref
+				f += ".preference"
+			}
+			out.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(out.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+				corev1.PreferredSchedulingTerm{Weight: p.weight(s, fmt.Sprintf("%s.preferred[%d]", field, i)), Preference: p.nodeSelectorTerm(term, f)})
+		}
+	}
+	if pa := getMap(a, "pod_affinity"); pa != nil {
+		required, preferred := p.podAffinity(pa, "affinity.pod_affinity")
+		out.PodAffinity = &corev1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution:  required,
+			PreferredDuringSchedulingIgnoredDuringExecution: preferred,
+		}
+	}
+	if pa := getMap(a, "pod_anti_affinity"); pa != nil {
+		required, preferred := p.podAffinity(pa, "affinity.pod_anti_affinity")
+		out.PodAntiAffinity = &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution:  required,
+			PreferredDuringSchedulingIgnoredDuringExecution: preferred,
+		}
+	}
+	return out
+}
+
+// nodeSelectorTerm reads {match_expressions, match_fields}.
+func (p *schedulingParser) nodeSelectorTerm(s *structpb.Struct, field string) corev1.NodeSelectorTerm {
+	term := corev1.NodeSelectorTerm{
+		MatchExpressions: p.nodeRequirements(getList(s, "match_expressions"), field+".match_expressions"),
+		MatchFields:      p.nodeRequirements(getList(s, "match_fields"), field+".match_fields"),
+	}
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		p.violation(field, "needs match_expressions or match_fields")
+	}
+	return term
+}
+
+func (p *schedulingParser) nodeRequirements(list []*structpb.Value, field string) []corev1.NodeSelectorRequirement {
+	var out []corev1.NodeSelectorRequirement
+	for i, v := range list {
+		f := fmt.Sprintf("%s[%d]", field, i)
+		s := p.object(v, f)
+		if s == nil {
+			continue
+		}
+		r := corev1.NodeSelectorRequirement{
+			Key:      getString(s, "key", ""),
+			Operator: corev1.NodeSelectorOperator(getString(s, "operator", "")),
+			Values:   getStringSlice(s, "values"),
+		}
+		if r.Key == "" {
+			p.violation(f+".key", "is required")
+		}
+		switch r.Operator {
+		case corev1.NodeSelectorOpIn, corev1.NodeSelectorOpNotIn:
+			if len(r.Values) == 0 {
+				p.violation(f+".values", fmt.Sprintf("must not be empty with operator %s", r.Operator))
+			}
+		case corev1.NodeSelectorOpExists, corev1.NodeSelectorOpDoesNotExist:
+			if len(r.Values) > 0 {
+				p.violation(f+".values", fmt.Sprintf("must be empty with operator %s", r.Operator))
+			}
+		case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+			if len(r.Values) != 1 {
+				p.violation(f+".values", fmt.Sprintf("must be a single integer with operator %s", r.Operator))
+			} else if _, err := strconv.ParseInt(r.Values[0], 10, 64); err != nil {
+				p.violation(f+".values", fmt.Sprintf("must be a single integer with operator %s", r.Operator))
+			}
+		default:
+			p.violation(f+".operator", "must be In, NotIn, Exists, DoesNotExist, Gt or Lt")
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// podAffinity reads the required and preferred terms of pod_affinity or
+// pod_anti_affinity.
+func (p *schedulingParser) podAffinity(s *structpb.Struct, field string) ([]corev1.PodAffinityTerm, []corev1.WeightedPodAffinityTerm) {
+	var required []corev1.PodAffinityTerm
+	for i, v := range listOrOne(s.Fields["required"]) {
+		f := fmt.Sprintf("%s.required[%d]", field, i)
+		if t := p.object(v, f); t != nil {
+			required = append(required, p.podAffinityTerm(t, f))
+		}
+	}
+	var preferred []corev1.WeightedPodAffinityTerm
+	for i, v := range listOrOne(s.Fields["preferred"]) {
+		f := fmt.Sprintf("%s.preferred[%d]", field, i)
+		t := p.object(v, f)
+		if t == nil {
+			continue
+		}
+		term, termField := t, f
+		if nested := getMap(t, "pod_affinity_term"); nested != nil {
+			term, termField = nested, f+".pod_affinity_term"
+		}
+		preferred = append(preferred, corev1.WeightedPodAffinityTerm{
+			Weight:          p.weight(t, f),
+			PodAffinityTerm: p.podAffinityTerm(term, termField),
+		})
+	}
+	return required, preferred
+}
+
+// podAffinityTerm reads {label_selector, topology_key, namespaces,
+// namespace_selector}. match_labels and match_expressions may also be given
+// directly in place of label_selector.
+func (p *schedulingParser) podAffinityTerm(s *structpb.Struct, field string) corev1.PodAffinityTerm {
+	term := corev1.PodAffinityTerm{
+		TopologyKey: getString(s, "topology_key", defaultTopologyKey),
+		Namespaces:  getStringSlice(s, "namespaces"),
+	}
+	if ls := getMap(s, "label_selector"); ls != nil {
+		term.LabelSelector = p.labelSelector(ls, field+".label_selector")
+	} else if getMap(s, "match_labels") != nil || getList(s, "match_expressions") != nil {
+		term.LabelSelector = p.labelSelector(s, field)
+	} else {
+		p.violation(field+".label_selector", "is required")
+	}
+	if ns := getMap(s, "namespace_selector"); ns != nil {
+		term.NamespaceSelector = p.labelSelector(ns, field+".namespace_selector")
+	}
+	if errs := validation.IsQualifiedName(term.TopologyKey); len(errs) > 0 {
+		p.violation(field+".topology_key", strings.Join(errs, "; "))
+	}
+	return term
+}
+
+// labelSelector reads {match_labels, match_expressions}.
+func (p *schedulingParser) labelSelector(s *structpb.Struct, field string) *metav1.LabelSelector {
+	ls := &metav1.LabelSelector{}
+	if ml := getMap(s, "match_labels"); ml != nil {
+		ls.MatchLabels = make(map[string]string, len(ml.Fields))
+		for k, v := range ml.Fields {
+			ls.MatchLabels[k] = v.GetStringValue()
+		}
+	}
+	for i, v := range getList(s, "match_expressions") {
+		f := fmt.Sprintf("%s.match_expressions[%d]", field, i)
+		e := p.object(v, f)
+		if e == nil {
+			continue
+		}
+		r := metav1.LabelSelectorRequirement{
+			Key:      getString(e, "key", ""),
+			Operator: metav1.LabelSelectorOperator(getString(e, "operator", "")),
+			Values:   getStringSlice(e, "values"),
+		}
+		if r.Key == "" {
+			p.violation(f+".key", "is required")
+		}
+		switch r.Operator {
+		case metav1.LabelSelectorOpIn, metav1.LabelSelectorOpNotIn:
+			if len(r.Values) == 0 {
+				p.violation(f+".values", fmt.Sprintf("must not be empty with operator %s", r.Operator))
+			}
+		case metav1.LabelSelectorOpExists, metav1.LabelSelectorOpDoesNotExist:
+			if len(r.Values) > 0 {
+				p.violation(f+".values", fmt.Sprintf("must be empty with operator %s", r.Operator))
+			}
+		default:
+			p.violation(f+".operator", "must be In, NotIn, Exists or DoesNotExist")
+		}
+		ls.MatchExpressions = append(ls.MatchExpressions, r)
+	}
+	if len(ls.MatchLabels) == 0 && len(ls.MatchExpressions) == 0 {
+		p.violation(field, "needs match_labels or match_expressions")
+	}
+	return ls
+}
+
+func (p *schedulingParser) topologySpread(list []*structpb.Value) []corev1.TopologySpreadConstraint {
+	var out []corev1.TopologySpreadConstraint
+	for i, v := range list {
+		field := fmt.Sprintf("topology_spread_constraints[%d]", i)
+		s := p.object(v, field)
+		if s == nil {
+			continue
+		}
+		c := corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       getString(s, "topology_key", ""),
+			WhenUnsatisfiable: corev1.UnsatisfiableConstraintAction(getString(s, "when_unsatisfiable", string(corev1.DoNotSchedule))),
+			MatchLabelKeys:    getStringSlice(s, "match_label_keys"),
+		}
+		if _, set := s.Fields["max_skew"]; set {
+			c.MaxSkew = int32(getInt(s, "max_skew"))
+			if c.MaxSkew < 1 {
+				p.violation(field+".max_skew", "must be at least 1")
+			}
+		}
+		if c.TopologyKey == "" {
+			p.violation(field+".topology_key", "is required")
+		} else if errs := validation.IsQualifiedName(c.TopologyKey); len(errs) > 0 {
+			p.violation(field+".topology_key", strings.Join(errs, "; "))
+		}
+		switch c.WhenUnsatisfiable {
+		case corev1.DoNotSchedule, corev1.ScheduleAnyway:
+		default:
+			p.violation(field+".when_unsatisfiable", "must be DoNotSchedule or ScheduleAnyway")
+		}
+		if _, set := s.Fields["min_domains"]; set {
+			n := int32(getInt(s, "min_domains"))
+			if n < 1 {
+				p.violation(field+".min_domains", "must be at least 1")
+			} else if c.WhenUnsatisfiable != corev1.DoNotSchedule {
+				p.violation(field+".min_domains", "requires when_unsatisfiable DoNotSchedule")
+			}
+			c.MinDomains = &n
+		}
+		if ls := getMap(s, "label_selector"); ls != nil {
+			c.LabelSelector = p.labelSelector(ls, field+".label_selector")
+		} else {
+			// By default, spread script pods among themselves
+			c.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"executor": "script"}}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// weight reads the weight of a preferred term, 1-100.
+func (p *schedulingParser) weight(s *structpb.Struct, field string) int32 {
+	w := getInt(s, "weight")
+	if w < 1 || w > 100 {
+		p.violation(field+".weight", "must be between 1 and 100")
+	}
+	return int32(w)
+}
+
+func (p *schedulingParser) object(v *structpb.Value, field string) *structpb.Struct {
+	s := v.GetStructValue()
+	if s == nil {
+		p.violation(field, "must be an object")
+	}
+	return s
+}
+
+// listOrOne returns the items of a list value, or a single object value as a
+// one-item list.
+func listOrOne(v *structpb.Value) []*structpb.Value {
+	if v == nil {
+		return nil
+	}
+	if l := v.GetListValue(); l != nil {
+		return l.Values
+	}
+	return []*structpb.Value{v}
+}
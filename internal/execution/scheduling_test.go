@@ -0,0 +1,50 @@
+package execution
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rakeshavasarala/script-executor/internal/config"
+)
+
+func TestParseSchedulingTolerations(t *testing.T) {
+	policy := config.TolerationPolicyConfig{
+		AllowedKeys: []string{"dedicated", "example.com/*"},
+		DeniedKeys:  []string{"node-role.kubernetes.io/control-plane"},
+	}
+	tests := []struct {
+		name       string
+		toleration map[string]interface{}
+		violations []string
+	}{
+		{name: "allowed key", toleration: map[string]interface{}{"key": "dedicated", "value": "batch", "effect": "NoSchedule"}},
+		{name: "allowed prefix", toleration: map[string]interface{}{"key": "example.com/gpu", "operator": "Exists"}},
+		{name: "denied key", toleration: map[string]interface{}{"key": "node-role.kubernetes.io/control-plane", "operator": "Exists"}, violations: []string{"tolerations[0].key"}},
+		{name: "key not allowed", toleration: map[string]interface{}{"key": "spot", "value": "true"}, violations: []string{"tolerations[0].key"}},
+		{name: "every taint", toleration: map[string]interface{}{"operator": "Exists"}, violations: []string{"tolerations[0].key"}},
+		{name: "equal without key", toleration: map[string]interface{}{"value": "x"}, violations: []string{"tolerations[0].key", "tolerations[0].key"}},
+		{name: "exists with value", toleration: map[string]interface{}{"key": "dedicated", "operator": "Exists", "value": "x"}, violations: []string{"tolerations[0].value"}},
+		{name: "bad effect", toleration: map[string]interface{}{"key": "dedicated", "value": "x", "effect": "Evict"}, violations: []string{"tolerations[0].effect"}},
+		{
+			name:       "toleration_seconds without NoExecute",
+			toleration: map[string]interface{}{"key": "dedicated", "value": "x", "effect": "NoSchedule", "toleration_seconds": 60},
+			violations: []string{"tolerations[0].toleration_seconds"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := mustStruct(t, map[string]interface{}{"tolerations": []interface{}{tt.toleration}})
+			tolerations, _, _, violations := parseScheduling(params, policy)
+			var fields []string
+			for _, v := range violations {
+				fields = append(fields, v.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.violations) {
+				t.Fatalf("violations = %v, want %v", violations, tt.violations)
+			}
+			if tt.violations == nil && len(tolerations) != 1 {
+				t.Errorf("tolerations = %v, want one", tolerations)
+			}
+		})
+	}
+}
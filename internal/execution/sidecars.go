@@ -0,0 +1,159 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/image"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/utils/ptr"
+)
+
+// maxSidecars bounds the catalog containers one execution can attach.
+const maxSidecars = 8
+
+// Sidecar is a catalog container attached to the script's pod: an init step
+// that runs before the script, or a native sidecar that runs next to it.
+type Sidecar struct {
+	// Name is the catalog name, also used as the container name.
+	Name       string
+	Image      string
+	PullSecret string
+	Init       bool
+	Command    []string
+	Args       []string
+	Env        map[string]string
+	Resources  corev1.ResourceRequirements
+}
+
+// resolveSidecars looks up the sidecars parameter, a list of catalog names,
+// in the sidecar catalog, and checks every image against the image policy.
+func (m *Manager) resolveSidecars(ctx context.Context, params *structpb.Struct) ([]Sidecar, error) {
+	names := getStringSlice(params, "sidecars")
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if len(names) > maxSidecars {
+		return nil, sidecarError("sidecars", fmt.Sprintf("at most %d sidecars may be attached", maxSidecars))
+	}
+	if err := m.catalog.Load(ctx); err != nil {
+		return nil, fmt.Errorf("load image catalog: %w", err)
+	}
+
+	var sidecars []Sidecar
+	var violations []*executorv1.FieldViolation
+	seen := make(map[string]bool, len(names))
+	for i, name := range names {
+		field := fmt.Sprintf("sidecars[%d]", i)
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 || name == "script" {
+			violations = append(violations, &executorv1.FieldViolation{Field: field, Description: fmt.Sprintf("%q is not a valid sidecar name", name)})
+			continue
+		}
+		if seen[name] {
+			violations = append(violations, &executorv1.FieldViolation{Field: field, Description: fmt.Sprintf("%s is attached twice", name)})
+			continue
+		}
+		seen[name] = true
+		entry, err := m.catalog.Sidecar(name)
+		if err != nil {
+			violations = append(violations, &executorv1.FieldViolation{Field: field, Description: err.Error()})
+			continue
+		}
+		if err := m.validator.Validate(entry.Image); err != nil {
+			violations = append(violations, &executorv1.FieldViolation{Field: field, Description: err.Error()})
+			continue
+		}
+		resources, err := sidecarResources(entry.Resources, buildResources(nil, m.config))
+		if err != nil {
+			violations = append(violations, &executorv1.FieldViolation{Field: field, Description: err.Error()})
+			continue
+		}
+		sidecars = append(sidecars, Sidecar{
+			Name:       name,
+			Image:      entry.Image,
+			PullSecret: entry.PullSecret,
+			Init:       entry.Init,
+			Command:    entry.Command,
+			Args:       entry.Args,
+			Env:        entry.Env,
+			Resources:  resources,
+		})
+	}
+	if len(violations) > 0 {
+		return nil, &DetailedError{
+			Code:       CodeInvalidParameter,
+			Message:    fmt.Sprintf("invalid sidecars: %s: %s", violations[0].Field, violations[0].Description),
+			Violations: violations,
+		}
+	}
+	return sidecars, nil
+}
+
+// sidecarResources applies a catalog entry's resources over the defaults.
+func sidecarResources(r image.SidecarResources, defaults corev1.ResourceRequirements) (corev1.ResourceRequirements, error) {
+	out := *defaults.DeepCopy()
+	set := func(list corev1.ResourceList, values map[string]string) error {
+		for name, v := range values {
+			q, err := resource.ParseQuantity(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s quantity %q", name, v)
+			}
+			list[corev1.ResourceName(strings.ReplaceAll(name, "_", "-"))] = q
+		}
+		return nil
+	}
+	if err := set(out.Requests, r.Requests); err != nil {
+		return out, err
+	}
+	if err := set(out.Limits, r.Limits); err != nil {
+		return out, err
+	}
+	// A default request must not exceed a lower limit from the catalog
+	for name, req := range out.Requests {
+		if lim, ok := out.Limits[name]; ok && req.Cmp(lim) > 0 {
+			out.Requests[name] = lim
+		}
+	}
+	return out, nil
+}
+
+func sidecarError(field, desc string) *DetailedError {
+	return &DetailedError{
+		Code:    CodeInvalidParameter,
+		Message: "invalid sidecars: " + desc,
+		Violations: []*executorv1.FieldViolation{
+			{Field: field, Description: desc},
+		},
+	}
+}
+
+// buildSidecar returns the init container for a sidecar, hardened like the
+// script container and sharing its workspace and /tmp. Native sidecars
+// (restartPolicy Always) are stopped by the kubelet once the script exits.
+func (b *JobBuilder) buildSidecar(ctx *Context, s Sidecar) corev1.Container {
+	c := corev1.Container{
+		Name:            s.Name,
+		Image:           s.Image,
+		ImagePullPolicy: ctx.ImagePullPolicy,
+		Command:         s.Command,
+		Args:            s.Args,
+		SecurityContext: b.containerSecurityContext(),
+		Resources:       s.Resources,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "workspace", MountPath: "/workspace"},
+			{Name: "tmp", MountPath: "/tmp"},
+		},
+	}
+	for _, k := range sortedKeys(s.Env) {
+		c.Env = append(c.Env, corev1.EnvVar{Name: k, Value: s.Env[k]})
+	}
+	if !s.Init {
+		c.RestartPolicy = ptr.To(corev1.ContainerRestartPolicyAlways)
+	}
+	return c
+}
@@ -0,0 +1,281 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// logFlushInterval bounds how long output lines are buffered before they are sent.
+	logFlushInterval = 500 * time.Millisecond
+	// logChunkLines is the maximum number of lines per progress message.
+	logChunkLines = 100
+	// logDrainTimeout bounds how long we wait for the log stream to end after the Job finished.
+	logDrainTimeout = 10 * time.Second
+)
+
+// SendFunc delivers a progress message to the client.
+type SendFunc func(*executorv1.ExecuteProgress) error
+
+// ExecuteStream runs a script.run step, sending pod phase transitions, live log
+// output and heartbeats through send, and finally the result with STAGE_DONE.
+func (m *Manager) ExecuteStream(ctx context.Context, req *executorv1.ExecuteRequest, send SendFunc) error {
+	ps := &progressStream{send: send, started: time.Now()}
+	if err := ps.emit(executorv1.ExecuteProgress_STAGE_STARTING, "Validating and preparing script execution...", nil); err != nil {
+		return err
+	}
+
+	p, resp := m.prepare(ctx, req, true)
+	if resp != nil {
+		return ps.done(resp)
+	}
+	ps.timeout = p.timeout
+	p.bindDeadline(ctx)
+
+	job, err := m.launch(ctx, p)
+	if err != nil {
+		return ps.done(errorResponse(err, p.startTime))
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		ps.heartbeats(streamCtx, m.config.HeartbeatInterval())
+	}()
+
+	var logsWG sync.WaitGroup
+	logsCtx, stopLogs := context.WithCancel(streamCtx)
+	defer stopLogs()
+
+	onPhase := func(phase Phase, pod *corev1.Pod) {
+		podName := ""
+		if pod != nil {
+			podName = pod.Name
+		}
+		ps.phase(phase, podName, job.Name)
+
+		// Follow the log once the container has started; for very short
+		// scripts that may only be observed as completing.
+		if (phase == PhaseRunning || phase == PhaseCompleting) && pod != nil && !ps.following {
+			ps.following = true
+			logsWG.Add(1)
+			go func() {
+				defer logsWG.Done()
+				ps.followLogs(logsCtx, m.monitor, pod.Name)
+			}()
+		}
+	}
+
+	result := m.complete(ctx, p, job, onPhase)
+
+	// Let the log stream drain before reporting the result.
+	drained := make(chan struct{})
+	go func() {
+		logsWG.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(logDrainTimeout):
+		stopLogs()
+		<-drained
+	}
+
+	cancel()
+	<-heartbeatDone
+	return ps.done(result)
+}
+
+// progressStream serializes progress messages from the phase callback, the log
+// follower and the heartbeat loop onto a single gRPC stream.
+type progressStream struct {
+	send      SendFunc
+	started   time.Time
+	timeout   time.Duration
+	following bool
+
+	mu       sync.Mutex
+	stage    executorv1.ExecuteProgress_Stage
+	lastSent time.Time
+	err      error
+}
+
+func (ps *progressStream) emit(stage executorv1.ExecuteProgress_Stage, message string, metadata map[string]interface{}) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.emitLocked(stage, message, metadata, nil)
+}
+
+func (ps *progressStream) emitLocked(stage executorv1.ExecuteProgress_Stage, message string, metadata map[string]interface{}, result *executorv1.ExecuteResponse) error {
+	if ps.err != nil {
+		return ps.err
+	}
+	msg := &executorv1.ExecuteProgress{
+		Stage:           stage,
+		PercentComplete: ps.percent(stage),
+		Message:         message,
+		Timestamp:       timestamppb.Now(),
+		Result:          result,
+	}
+	if metadata != nil {
+		if md, err := structpb.NewStruct(metadata); err == nil {
+			msg.Metadata = md
+		}
+	}
+	ps.stage = stage
+	ps.lastSent = time.Now()
+	ps.err = ps.send(msg)
+	return ps.err
+}
+
+// percent estimates progress from elapsed time against the timeout.
+func (ps *progressStream) percent(stage executorv1.ExecuteProgress_Stage) int32 {
+	switch stage {
+	case executorv1.ExecuteProgress_STAGE_DONE:
+		return 100
+	case executorv1.ExecuteProgress_STAGE_UNSPECIFIED:
+		return 0
+	}
+	if ps.timeout <= 0 {
+		return 0
+	}
+	pct := int32(time.Since(ps.started) * 100 / ps.timeout)
+	if pct < 1 {
+		pct = 1
+	}
+	if pct > 99 {
+		pct = 99
+	}
+	return pct
+}
+
+func (ps *progressStream) phase(phase Phase, podName, jobName string) {
+	stage := executorv1.ExecuteProgress_STAGE_STARTING
+	var message string
+	switch phase {
+	case PhaseJobCreated:
+		message = fmt.Sprintf("Job %s created", jobName)
+	case PhasePodScheduled:
+		message = fmt.Sprintf("Pod %s scheduled", podName)
+	case PhaseImagePulled:
+		message = "Image pulled"
+	case PhaseRunning:
+		stage = executorv1.ExecuteProgress_STAGE_RUNNING
+		message = "Script container running"
+	case PhaseCompleting:
+		stage = executorv1.ExecuteProgress_STAGE_COMPLETING
+		message = "Script finished, collecting results..."
+	}
+	ps.emit(stage, message, map[string]interface{}{
+		"type":     "phase",
+		"phase":    string(phase),
+		"job_name": jobName,
+		"pod_name": podName,
+	})
+}
+
+// logLine is a line of script output and the stream it was written to.
+type logLine struct {
+	stream string
+	text   string
+}
+
+// followLogs sends the pod log as it is produced. Consecutive lines of the
+// same stream are sent together, in container log order: lines of one stream
+// keep their order, while stdout and stderr are tagged by separate pipelines
+// and interleave only approximately as the script wrote them.
+func (ps *progressStream) followLogs(ctx context.Context, monitor *Monitor, podName string) {
+	lines := make(chan logLine, logChunkLines)
+	go func() {
+		defer close(lines)
+		monitor.FollowLogs(ctx, podName, func(stream, text string) {
+			select {
+			case lines <- logLine{stream: stream, text: text}:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	var buf []string
+	bufStream := streamStdout
+	flush := func() {
+		if len(buf) == 0 {
+			return
+		}
+		ps.mu.Lock()
+		stage := ps.stage
+		if stage != executorv1.ExecuteProgress_STAGE_COMPLETING {
+			stage = executorv1.ExecuteProgress_STAGE_RUNNING
+		}
+		ps.emitLocked(stage, strings.Join(buf, "\n"), map[string]interface{}{
+			"type":     "output",
+			"stream":   bufStream,
+			"pod_name": podName,
+			"lines":    float64(len(buf)),
+		}, nil)
+		ps.mu.Unlock()
+		buf = buf[:0]
+	}
+
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				flush()
+				return
+			}
+			if line.stream != bufStream {
+				flush()
+				bufStream = line.stream
+			}
+			buf = append(buf, line.text)
+			if len(buf) >= logChunkLines {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// heartbeats sends a heartbeat whenever nothing was sent for interval.
+func (ps *progressStream) heartbeats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ps.mu.Lock()
+			if time.Since(ps.lastSent) >= interval {
+				ps.emitLocked(ps.stage, "heartbeat", map[string]interface{}{
+					"type":            "heartbeat",
+					"elapsed_seconds": time.Since(ps.started).Seconds(),
+				}, nil)
+			}
+			ps.mu.Unlock()
+		}
+	}
+}
+
+func (ps *progressStream) done(resp *executorv1.ExecuteResponse) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.emitLocked(executorv1.ExecuteProgress_STAGE_DONE,
+		fmt.Sprintf("Execution completed with status %s", resp.Status),
+		nil, resp)
+}
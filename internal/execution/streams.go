@@ -0,0 +1,328 @@
+package execution
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+	// streamOutputs carries the content of the $OCR_OUTPUTS file.
+	streamOutputs = "outputs"
+	// streamControl carries notices from the wrapper itself.
+	streamControl = "control"
+	// streamArtifact starts a collected file; streamArtifactData carries its
+	// content, base64-encoded.
+	streamArtifact     = "artifact"
+	streamArtifactData = "artifact_data"
+)
+
+// runWrapper runs the script command ("$@") inside the script container. It
+// only needs a POSIX /bin/sh with base64, and exits with the script's exit
+// code. The container log is the only channel back to the executor, so every
+// line the wrapper writes carries a tag. In order, it:
+//
+//  1. Puts the tool directories in $OCR_TOOLS_PATH first on PATH.
+//  2. Copies the directory $OCR_FILES into /workspace, and makes the files
+//     listed in $OCR_FILES_SENSITIVE private to the script user.
+//  3. Makes the file named by $OCR_STDIN, if any, the script's stdin, and
+//     empties $OCR_OUTPUTS.
+//  4. Runs the script, prefixing every line it writes with "O " (stdout) or
+//     "E " (stderr). Each stream is tagged by its own pipeline, so lines of
+//     one stream keep their order but the two interleave only approximately.
+//     The script runs with fd 3, the wrapper's untagged stdout, closed, so it
+//     cannot write protocol lines.
+//  5. Writes the content of $OCR_OUTPUTS tagged "R ", or an
+//     "X outputs-too-large <size>" notice if it exceeds $OCR_OUTPUTS_MAX bytes.
+//  6. Removes the sensitive files, so they cannot be collected.
+//  7. Writes every regular file under /workspace matching a glob in
+//     $OCR_ARTIFACTS as an "A <path>" line followed by its content in base64
+//     tagged "B ", as long as the total stays within $OCR_ARTIFACTS_MAX bytes;
+//     larger files get an "X artifact-too-large <size> <path>" notice.
+const runWrapper = `if [ -n "${OCR_TOOLS_PATH:-}" ]; then PATH="$OCR_TOOLS_PATH:$PATH"; export PATH; fi
+if [ -n "${OCR_FILES:-}" ]; then
+  cp -R "$OCR_FILES/." /workspace/ && chmod -R u+w /workspace || exit 1
+  if [ -n "${OCR_FILES_SENSITIVE:-}" ]; then (cd /workspace && set -f && chmod 600 $OCR_FILES_SENSITIVE) || exit 1; fi
+fi
+if [ -n "${OCR_STDIN:-}" ]; then exec < "$OCR_STDIN"; fi
+if [ -n "${OCR_OUTPUTS:-}" ]; then : > "$OCR_OUTPUTS"; fi
+tag() { while IFS= read -r line || [ -n "$line" ]; do printf '%s %s\n' "$1" "$line"; done; }
+{ { { "$@" 3>&-; echo $? > /tmp/.ocr-exit; } | tag O; } 2>&1 1>&3 | tag E; } 3>&1
+read -r rc < /tmp/.ocr-exit || rc=1
+if [ -s "${OCR_OUTPUTS:-}" ]; then
+  size=$(wc -c < "$OCR_OUTPUTS")
+  if [ "$size" -le "${OCR_OUTPUTS_MAX:-65536}" ]; then tag R < "$OCR_OUTPUTS"; else printf 'X outputs-too-large %s\n' $size; fi
+fi
+if [ -n "${OCR_FILES_SENSITIVE:-}" ]; then (cd /workspace && set -f && rm -f $OCR_FILES_SENSITIVE); fi
+if [ -n "${OCR_ARTIFACTS:-}" ]; then
+  (cd /workspace && total=0 && set -f && for pattern in $OCR_ARTIFACTS; do set +f; for f in $pattern; do
+    [ -f "$f" ] || continue
+    size=$(($(wc -c < "$f")))
+    if [ $((total + size)) -gt "${OCR_ARTIFACTS_MAX:-4194304}" ]; then printf 'X artifact-too-large %s %s\n' "$size" "$f"; continue; fi
+    total=$((total + size))
+    printf 'A %s\n' "$f"; base64 < "$f" | tag B
+  done; set -f; done)
+fi
+exit "$rc"`
+
+// wrapCommand runs command under runWrapper.
+func wrapCommand(command []string) []string {
+	return append([]string{"/bin/sh", "-c", runWrapper, "ocr-run"}, command...)
+}
+
+// parseLogLine returns the stream a container log line was written to and its
+// original text. Untagged lines (e.g. from the shell itself) count as stdout.
+func parseLogLine(line string) (stream, text string) {
+	switch {
+	case strings.HasPrefix(line, "O "):
+		return streamStdout, line[2:]
+	case strings.HasPrefix(line, "E "):
+		return streamStderr, line[2:]
+	case strings.HasPrefix(line, "R "):
+		return streamOutputs, line[2:]
+	case strings.HasPrefix(line, "X "):
+		return streamControl, line[2:]
+	case strings.HasPrefix(line, "A "):
+		return streamArtifact, line[2:]
+	case strings.HasPrefix(line, "B "):
+		return streamArtifactData, line[2:]
+	}
+	return streamStdout, line
+}
+
+// maxOutputsCapture bounds the $OCR_OUTPUTS content kept from the log; the
+// wrapper enforces the configured limit, this only guards against misuse.
+const maxOutputsCapture = 1024 * 1024
+
+// maxArtifactsCapture bounds the base64 artifact content kept from the log,
+// for the same reason.
+const maxArtifactsCapture = 64 * 1024 * 1024
+
+// CollectedArtifact is a workspace file the wrapper wrote to the log.
+type CollectedArtifact struct {
+	// Path is relative to /workspace.
+	Path string
+	Data []byte
+	// Size is the file size.
+	Size int64
+	// Error is why the file was not collected, if it was not.
+	Error string
+
+	encoded bytes.Buffer
+}
+
+// CaptureLimits bounds how much of each stream is returned inline.
+type CaptureLimits struct {
+	// HeadBytes and TailBytes are kept from the start and end of each stream.
+	HeadBytes int
+	TailBytes int
+}
+
+// capturedLog is a tagged container log split by stream.
+type capturedLog struct {
+	Stdout      string
+	Stderr      string
+	StdoutBytes int64
+	StderrBytes int64
+	// Truncated is set when stdout or stderr exceeded the capture limits.
+	Truncated       bool
+	StdoutTruncated bool
+	StderrTruncated bool
+	Outputs         string
+	// OutputsTooLarge is the size of an $OCR_OUTPUTS file that was not
+	// captured because it exceeded the limit, or zero.
+	OutputsTooLarge int
+	Artifacts       []*CollectedArtifact
+}
+
+// captureLog reads a tagged container log, keeping the head and tail of each
+// stream within limits. The raw log is copied to spill, if set, as it is read.
+func captureLog(r io.Reader, limits CaptureLimits, spill io.Writer) (*capturedLog, error) {
+	if spill != nil {
+		r = io.TeeReader(r, spill)
+	}
+	stdout := newBoundedBuffer(limits)
+	stderr := newBoundedBuffer(limits)
+	var outputs, control, header bytes.Buffer
+	captured := &capturedLog{}
+	var artifact *CollectedArtifact
+	artifactBytes := 0
+
+	// Lines may be longer than the reader's buffer; only the first piece of a
+	// line carries its tag.
+	br := bufio.NewReaderSize(r, 64*1024)
+	var dst io.Writer
+	for {
+		piece, err := br.ReadSlice('\n')
+		if len(piece) > 0 {
+			if dst == nil {
+				var stream string
+				stream, piece = parseLogTag(piece)
+				switch stream {
+				case streamStderr:
+					dst = stderr
+				case streamOutputs:
+					dst = limitedWriter{&outputs, maxOutputsCapture}
+				case streamControl:
+					dst = &control
+				case streamArtifact:
+					dst = &header
+				case streamArtifactData:
+					dst = io.Discard
+					if artifact != nil {
+						dst = limitedWriter{&artifact.encoded, maxArtifactsCapture - artifactBytes}
+					}
+				default:
+					dst = stdout
+				}
+			}
+			dst.Write(piece)
+			if piece[len(piece)-1] == '\n' {
+				switch dst {
+				case &control:
+					parseControl(strings.TrimSpace(control.String()), captured)
+					control.Reset()
+				case &header:
+					if artifact != nil {
+						artifactBytes += artifact.encoded.Len()
+					}
+					artifact = &CollectedArtifact{Path: strings.TrimSuffix(header.String(), "\n")}
+					captured.Artifacts = append(captured.Artifacts, artifact)
+					header.Reset()
+				}
+				dst = nil
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	captured.Stdout, captured.StdoutBytes = stdout.String(), stdout.total
+	captured.Stderr, captured.StderrBytes = stderr.String(), stderr.total
+	captured.StdoutTruncated, captured.StderrTruncated = stdout.truncated(), stderr.truncated()
+	captured.Truncated = captured.StdoutTruncated || captured.StderrTruncated
+	captured.Outputs = outputs.String()
+	for _, a := range captured.Artifacts {
+		if a.Error != "" {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(a.encoded.String())
+		if err != nil {
+			a.Error = "content is incomplete in the log"
+		}
+		a.Data, a.Size = data, int64(len(data))
+		a.encoded = bytes.Buffer{}
+	}
+	return captured, nil
+}
+
+// parseLogTag is parseLogLine for the first piece of a raw log line.
+func parseLogTag(line []byte) (stream string, text []byte) {
+	if len(line) >= 2 && line[1] == ' ' {
+		switch line[0] {
+		case 'O':
+			return streamStdout, line[2:]
+		case 'E':
+			return streamStderr, line[2:]
+		case 'R':
+			return streamOutputs, line[2:]
+		case 'X':
+			return streamControl, line[2:]
+		case 'A':
+			return streamArtifact, line[2:]
+		case 'B':
+			return streamArtifactData, line[2:]
+		}
+	}
+	return streamStdout, line
+}
+
+func parseControl(notice string, captured *capturedLog) {
+	if size, ok := strings.CutPrefix(notice, "outputs-too-large "); ok {
+		captured.OutputsTooLarge, _ = strconv.Atoi(strings.TrimSpace(size))
+	}
+	if rest, ok := strings.CutPrefix(notice, "artifact-too-large "); ok {
+		size, path, _ := strings.Cut(rest, " ")
+		a := &CollectedArtifact{Path: path, Error: "exceeds the artifact size limit"}
+		a.Size, _ = strconv.ParseInt(size, 10, 64)
+		captured.Artifacts = append(captured.Artifacts, a)
+	}
+}
+
+// boundedBuffer keeps the first HeadBytes and the last TailBytes written to it.
+type boundedBuffer struct {
+	limits CaptureLimits
+	head   []byte
+	tail   []byte
+	total  int64
+}
+
+func newBoundedBuffer(limits CaptureLimits) *boundedBuffer {
+	return &boundedBuffer{limits: limits}
+}
+
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	b.total += int64(n)
+	if room := b.limits.HeadBytes - len(b.head); room > 0 {
+		take := min(room, len(p))
+		b.head = append(b.head, p[:take]...)
+		p = p[take:]
+	}
+	if len(p) == 0 || b.limits.TailBytes <= 0 {
+		return n, nil
+	}
+	b.tail = append(b.tail, p...)
+	// Trim in batches so the copy cost stays linear in the input.
+	if len(b.tail) > 2*b.limits.TailBytes {
+		b.tail = append(b.tail[:0], b.tail[len(b.tail)-b.limits.TailBytes:]...)
+	}
+	return n, nil
+}
+
+func (b *boundedBuffer) truncated() bool {
+	return b.total > int64(b.limits.HeadBytes+b.limits.TailBytes)
+}
+
+// String returns the captured text, marking where bytes were dropped. Cuts
+// may split a UTF-8 sequence; invalid bytes are replaced so the text can be
+// stored in a Struct.
+func (b *boundedBuffer) String() string {
+	tail := b.tail
+	if len(tail) > b.limits.TailBytes {
+		tail = tail[len(tail)-b.limits.TailBytes:]
+	}
+	if !b.truncated() {
+		return strings.ToValidUTF8(string(b.head)+string(tail), "\uFFFD")
+	}
+	dropped := b.total - int64(len(b.head)) - int64(len(tail))
+	return strings.ToValidUTF8(string(b.head), "\uFFFD") +
+		fmt.Sprintf("\n... [%d bytes truncated] ...\n", dropped) +
+		strings.ToValidUTF8(string(tail), "\uFFFD")
+}
+
+// limitedWriter discards everything past n bytes.
+type limitedWriter struct {
+	buf *bytes.Buffer
+	n   int
+}
+
+func (w limitedWriter) Write(p []byte) (int, error) {
+	if room := w.n - w.buf.Len(); room > 0 {
+		w.buf.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,177 @@
+package execution
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		line, stream, text string
+	}{
+		{"O hello", streamStdout, "hello"},
+		{"E oops", streamStderr, "oops"},
+		{"R count=3", streamOutputs, "count=3"},
+		{"X outputs-too-large 99", streamControl, "outputs-too-large 99"},
+		{"A report.txt", streamArtifact, "report.txt"},
+		{"B aGk=", streamArtifactData, "aGk="},
+		{"sh: not found", streamStdout, "sh: not found"},
+		{"O", streamStdout, "O"},
+	}
+	for _, tt := range tests {
+		stream, text := parseLogLine(tt.line)
+		if stream != tt.stream || text != tt.text {
+			t.Errorf("parseLogLine(%q) = %q, %q; want %q, %q", tt.line, stream, text, tt.stream, tt.text)
+		}
+	}
+}
+
+func TestCaptureLog(t *testing.T) {
+	log := strings.Join([]string{
+		"O line 1",
+		"E warn 1",
+		"O line 2",
+		"R count=3",
+		"X outputs-too-large 70000",
+		"A reports/a.txt",
+		"B " + base64.StdEncoding.EncodeToString([]byte("artifact")),
+		"X artifact-too-large 9999999 big.bin",
+		"untagged",
+		"",
+	}, "\n")
+	var spill bytes.Buffer
+	got, err := captureLog(strings.NewReader(log), CaptureLimits{HeadBytes: 1024, TailBytes: 1024}, &spill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Stdout != "line 1\nline 2\nuntagged\n" {
+		t.Errorf("Stdout = %q", got.Stdout)
+	}
+	if got.Stderr != "warn 1\n" {
+		t.Errorf("Stderr = %q", got.Stderr)
+	}
+	if got.Outputs != "count=3\n" {
+		t.Errorf("Outputs = %q", got.Outputs)
+	}
+	if got.OutputsTooLarge != 70000 {
+		t.Errorf("OutputsTooLarge = %d, want 70000", got.OutputsTooLarge)
+	}
+	if got.Truncated || got.StdoutTruncated || got.StderrTruncated {
+		t.Error("log within limits is marked truncated")
+	}
+	if spill.String() != log {
+		t.Error("spill is not a copy of the raw log")
+	}
+	if len(got.Artifacts) != 2 {
+		t.Fatalf("Artifacts = %d, want 2", len(got.Artifacts))
+	}
+	if a := got.Artifacts[0]; a.Path != "reports/a.txt" || string(a.Data) != "artifact" || a.Size != 8 || a.Error != "" {
+		t.Errorf("artifact 0 = %+v", a)
+	}
+	if a := got.Artifacts[1]; a.Path != "big.bin" || a.Size != 9999999 || a.Error == "" {
+		t.Errorf("artifact 1 = %+v", a)
+	}
+}
+
+func TestCaptureLogTruncation(t *testing.T) {
+	tests := []struct {
+		name                             string
+		log                              string
+		stdoutTruncated, stderrTruncated bool
+		wantStdoutPrefix                 string
+		wantStdoutSuffix                 string
+		wantStdoutTotalBytes             int64
+	}{
+		{
+			name:                 "stdout over the limits",
+			log:                  "O " + strings.Repeat("a", 100) + "\nO " + strings.Repeat("b", 100) + "\nE short\n",
+			stdoutTruncated:      true,
+			wantStdoutPrefix:     strings.Repeat("a", 10),
+			wantStdoutSuffix:     strings.Repeat("b", 9) + "\n",
+			wantStdoutTotalBytes: 202,
+		},
+		{
+			name:                 "stderr over the limits",
+			log:                  "O short\nE " + strings.Repeat("e", 100) + "\n",
+			stderrTruncated:      true,
+			wantStdoutPrefix:     "short\n",
+			wantStdoutSuffix:     "short\n",
+			wantStdoutTotalBytes: 6,
+		},
+		{
+			// A line longer than the reader's buffer keeps its stream
+			name:                 "long stderr line",
+			log:                  "E " + strings.Repeat("e", 200*1024) + "\nO done\n",
+			stderrTruncated:      true,
+			wantStdoutPrefix:     "done\n",
+			wantStdoutSuffix:     "done\n",
+			wantStdoutTotalBytes: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := captureLog(strings.NewReader(tt.log), CaptureLimits{HeadBytes: 10, TailBytes: 10}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.StdoutTruncated != tt.stdoutTruncated || got.StderrTruncated != tt.stderrTruncated {
+				t.Errorf("StdoutTruncated, StderrTruncated = %v, %v; want %v, %v", got.StdoutTruncated, got.StderrTruncated, tt.stdoutTruncated, tt.stderrTruncated)
+			}
+			if got.Truncated != (tt.stdoutTruncated || tt.stderrTruncated) {
+				t.Errorf("Truncated = %v", got.Truncated)
+			}
+			if !strings.HasPrefix(got.Stdout, tt.wantStdoutPrefix) || !strings.HasSuffix(got.Stdout, tt.wantStdoutSuffix) {
+				t.Errorf("Stdout = %q", got.Stdout)
+			}
+			if got.StdoutBytes != tt.wantStdoutTotalBytes {
+				t.Errorf("StdoutBytes = %d, want %d", got.StdoutBytes, tt.wantStdoutTotalBytes)
+			}
+		})
+	}
+}
+
+func TestTruncationError(t *testing.T) {
+	criteria, violations := parseSuccessCriteria(mustStruct(t, map[string]interface{}{
+		"success_criteria": map[string]interface{}{"stdout_not_matches": []interface{}{"ERROR"}},
+	}))
+	if len(violations) > 0 {
+		t.Fatal(violations)
+	}
+	tests := []struct {
+		name   string
+		ctx    *Context
+		result *Result
+		want   []string
+	}{
+		{name: "nothing truncated", ctx: &Context{OutputFormat: OutputFormatJSON}, result: &Result{}},
+		{name: "text output", ctx: &Context{OutputFormat: OutputFormatText}, result: &Result{StdoutTruncated: true}},
+		{name: "json output", ctx: &Context{OutputFormat: OutputFormatJSON}, result: &Result{StdoutTruncated: true}, want: []string{"output_format"}},
+		{
+			name:   "extract",
+			ctx:    &Context{Extract: map[string]string{"b": "x", "a": "y"}},
+			result: &Result{StdoutTruncated: true},
+			want:   []string{"extract.a", "extract.b"},
+		},
+		{name: "stdout criteria", ctx: &Context{SuccessCriteria: criteria}, result: &Result{StdoutTruncated: true}, want: []string{"success_criteria.stdout_not_matches"}},
+		{name: "stdout criteria with stderr truncated", ctx: &Context{SuccessCriteria: criteria}, result: &Result{StderrTruncated: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := truncationError(tt.ctx, tt.result)
+			var got []string
+			if err != nil {
+				if err.Code != CodeOutputTruncated {
+					t.Errorf("Code = %s, want %s", err.Code, CodeOutputTruncated)
+				}
+				for _, v := range err.Violations {
+					got = append(got, v.Field)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("truncationError() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package execution
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/script"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// templateFuncs is the whole function set available to script templates, on
+// top of the text/template builtins. Nothing reaches the filesystem, the
+// environment or the network.
+var templateFuncs = template.FuncMap{
+	"quote": shellQuote,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"join": func(sep string, list []interface{}) string {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, sep)
+	},
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+}
+
+// renderScript renders a script loaded with template: true. The template sees
+// the execution context (ExecutionID, ClusterID, Namespace, User, RunbookID,
+// StartedAt, Labels) and the named inputs (Inputs); a reference to anything
+// else is an error rather than an empty string.
+func renderScript(content string, source *script.Source, params *structpb.Struct, execCtx *executorv1.ExecutionContext) (string, error) {
+	if source.Type == script.SourcePath || source.Bundle != nil {
+		return "", &DetailedError{
+			Code:    CodeInvalidParameter,
+			Message: "template is not supported for script_path scripts and bundles",
+			Violations: []*executorv1.FieldViolation{
+				{Field: "template", Description: "requires a single script with content"},
+			},
+		}
+	}
+	inputs, violations := parseInputs(params, source)
+	if len(violations) > 0 {
+		return "", &DetailedError{
+			Code:       CodeInvalidParameter,
+			Message:    fmt.Sprintf("invalid inputs: %s: %s", violations[0].Field, violations[0].Description),
+			Violations: violations,
+		}
+	}
+	if inputs == nil {
+		inputs = map[string]interface{}{}
+	}
+	labels := make(map[string]interface{}, len(execCtx.GetLabels()))
+	for k, v := range execCtx.GetLabels() {
+		labels[k] = v
+	}
+	startedAt := ""
+	if execCtx.GetStartedAt() != nil {
+		startedAt = execCtx.GetStartedAt().AsTime().UTC().Format(time.RFC3339)
+	}
+	data := map[string]interface{}{
+		"ExecutionID": execCtx.GetExecutionId(),
+		"ClusterID":   execCtx.GetClusterId(),
+		"Namespace":   execCtx.GetNamespace(),
+		"User":        execCtx.GetUser(),
+		"RunbookID":   execCtx.GetRunbookId(),
+		"StartedAt":   startedAt,
+		"Labels":      labels,
+		"Inputs":      inputs,
+	}
+
+	tmpl, err := template.New("script").Option("missingkey=error").Funcs(templateFuncs).Parse(content)
+	if err != nil {
+		return "", templateError(err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", templateError(err)
+	}
+	return out.String(), nil
+}
+
+func templateError(err error) *DetailedError {
+	return &DetailedError{
+		Code:    CodeTemplateFailed,
+		Message: fmt.Sprintf("render script template: %v", err),
+		Violations: []*executorv1.FieldViolation{
+			{Field: "template", Description: err.Error()},
+		},
+	}
+}
+
+// shellQuote quotes s as a single POSIX shell word.
+func shellQuote(v interface{}) string {
+	return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", `'\''`) + "'"
+}
@@ -0,0 +1,84 @@
+package execution
+
+import (
+	"testing"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/script"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRenderScript(t *testing.T) {
+	execCtx := &executorv1.ExecutionContext{
+		ExecutionId: "exec-1",
+		ClusterId:   "prod",
+		Namespace:   "web",
+		User:        "alice",
+		RunbookId:   "restart",
+		StartedAt:   timestamppb.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Labels:      map[string]string{"team": "sre"},
+	}
+	inline := &script.Source{Type: script.SourceInline}
+	tests := []struct {
+		name     string
+		content  string
+		source   *script.Source
+		inputs   map[string]interface{}
+		want     string
+		wantCode string
+	}{
+		{
+			name:    "execution context",
+			content: "{{.ExecutionID}} {{.ClusterID}} {{.Namespace}} {{.User}} {{.RunbookID}} {{.StartedAt}} {{.Labels.team}}",
+			want:    "exec-1 prod web alice restart 2024-01-02T03:04:05Z sre",
+		},
+		{
+			name:    "quoted inputs",
+			content: "kubectl rollout restart deploy/{{quote .Inputs.name}} --replicas={{.Inputs.count}}",
+			inputs:  map[string]interface{}{"name": "it's; rm -rf /", "count": 3},
+			want:    `kubectl rollout restart deploy/'it'\''s; rm -rf /' --replicas=3`,
+		},
+		{
+			name:    "functions",
+			content: `{{upper .Inputs.a}} {{lower "B"}} {{trim "  c "}} {{join "," .Inputs.list}} {{json .Inputs.list}} {{default "none" .Inputs.empty}}`,
+			inputs:  map[string]interface{}{"a": "x", "list": []interface{}{"p", 1}, "empty": ""},
+			want:    `X b c p,1 ["p",1] none`,
+		},
+		{name: "no inputs", content: "{{len .Inputs}}", want: "0"},
+		{name: "missing input", content: "{{.Inputs.missing}}", inputs: map[string]interface{}{"name": "x"}, wantCode: CodeTemplateFailed},
+		{name: "missing label", content: "{{.Labels.owner}}", wantCode: CodeTemplateFailed},
+		{name: "unknown field", content: "{{.Env}}", wantCode: CodeTemplateFailed},
+		{name: "unknown function", content: `{{env "HOME"}}`, wantCode: CodeTemplateFailed},
+		{name: "syntax error", content: "{{if}}", wantCode: CodeTemplateFailed},
+		{name: "invalid input name", content: "x", inputs: map[string]interface{}{"1x": "y"}, wantCode: CodeInvalidParameter},
+		{name: "script_path script", content: "x", source: &script.Source{Type: script.SourcePath}, wantCode: CodeInvalidParameter},
+		{name: "bundle", content: "x", source: &script.Source{Type: script.SourceInline, Bundle: &script.Bundle{}}, wantCode: CodeInvalidParameter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := tt.source
+			if source == nil {
+				source = inline
+			}
+			params := map[string]interface{}{}
+			if tt.inputs != nil {
+				params["inputs"] = tt.inputs
+			}
+			got, err := renderScript(tt.content, source, mustStruct(t, params), execCtx)
+			if tt.wantCode != "" {
+				de, ok := err.(*DetailedError)
+				if !ok || de.Code != tt.wantCode {
+					t.Fatalf("renderScript() error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("renderScript() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
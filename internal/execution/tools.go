@@ -0,0 +1,137 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// toolsVolumeName is the emptyDir the tools are copied into.
+	toolsVolumeName = "tools"
+	// toolsMountPath is where the tools volume is mounted; each tool has its
+	// own directory in it.
+	toolsMountPath = "/opt/tools"
+	// toolContainerPrefix prefixes the init container copying a tool.
+	toolContainerPrefix = "tool-"
+	// maxTools bounds the tools one execution can compose.
+	maxTools = 16
+)
+
+// Tool is a catalog tool copied into the script's pod.
+type Tool struct {
+	Name       string
+	Image      string
+	PullSecret string
+	Paths      []string
+	Bin        []string
+	Command    []string
+}
+
+// resolveTools looks up the tools parameter, a list of catalog names, in the
+// tool catalog, and checks every image against the image policy.
+func (m *Manager) resolveTools(ctx context.Context, params *structpb.Struct) ([]Tool, error) {
+	names := getStringSlice(params, "tools")
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if len(names) > maxTools {
+		return nil, &DetailedError{
+			Code:    CodeInvalidParameter,
+			Message: fmt.Sprintf("invalid tools: at most %d tools may be used", maxTools),
+			Violations: []*executorv1.FieldViolation{
+				{Field: "tools", Description: fmt.Sprintf("at most %d tools may be used", maxTools)},
+			},
+		}
+	}
+	if err := m.catalog.Load(ctx); err != nil {
+		return nil, fmt.Errorf("load image catalog: %w", err)
+	}
+
+	var tools []Tool
+	var violations []*executorv1.FieldViolation
+	seen := make(map[string]bool, len(names))
+	for i, name := range names {
+		field := fmt.Sprintf("tools[%d]", i)
+		if errs := validation.IsDNS1123Label(toolContainerPrefix + name); len(errs) > 0 {
+			violations = append(violations, &executorv1.FieldViolation{Field: field, Description: fmt.Sprintf("%q is not a valid tool name", name)})
+			continue
+		}
+		if seen[name] {
+			violations = append(violations, &executorv1.FieldViolation{Field: field, Description: fmt.Sprintf("%s is listed twice", name)})
+			continue
+		}
+		seen[name] = true
+		entry, err := m.catalog.Tool(name)
+		if err != nil {
+			violations = append(violations, &executorv1.FieldViolation{Field: field, Description: err.Error()})
+			continue
+		}
+		if err := m.validator.Validate(entry.Image); err != nil {
+			violations = append(violations, &executorv1.FieldViolation{Field: field, Description: err.Error()})
+			continue
+		}
+		tools = append(tools, Tool{
+			Name:       name,
+			Image:      entry.Image,
+			PullSecret: entry.PullSecret,
+			Paths:      entry.Paths,
+			Bin:        entry.Bin,
+			Command:    entry.Command,
+		})
+	}
+	if len(violations) > 0 {
+		return nil, &DetailedError{
+			Code:       CodeInvalidParameter,
+			Message:    fmt.Sprintf("invalid tools: %s: %s", violations[0].Field, violations[0].Description),
+			Violations: violations,
+		}
+	}
+	return tools, nil
+}
+
+// toolsPath returns the tool directories to put on the script's PATH, in the
+// order the tools were listed.
+func toolsPath(tools []Tool) string {
+	var dirs []string
+	for _, t := range tools {
+		if len(t.Bin) == 0 {
+			dirs = append(dirs, path.Join(toolsMountPath, t.Name))
+			continue
+		}
+		for _, b := range t.Bin {
+			// Bin entries stay below the tool's directory
+			dirs = append(dirs, path.Join(toolsMountPath, t.Name, path.Clean("/"+b)))
+		}
+	}
+	return strings.Join(dirs, ":")
+}
+
+// buildToolInit returns the init container copying a tool out of its image
+// into its directory of the tools volume. It runs with the script container's
+// security context, so the image must be able to run as a non-root user.
+func (b *JobBuilder) buildToolInit(ctx *Context, t Tool) corev1.Container {
+	dir := path.Join(toolsMountPath, t.Name)
+	command := t.Command
+	if len(command) == 0 {
+		command = append(append([]string{"cp", "-RL"}, t.Paths...), dir+"/")
+	}
+	return corev1.Container{
+		Name:            toolContainerPrefix + t.Name,
+		Image:           t.Image,
+		ImagePullPolicy: ctx.ImagePullPolicy,
+		Command:         command,
+		SecurityContext: b.containerSecurityContext(),
+		Resources:       buildResources(nil, b.config),
+		VolumeMounts: []corev1.VolumeMount{
+			// The subPath mount creates the tool's directory
+			{Name: toolsVolumeName, MountPath: dir, SubPath: t.Name},
+		},
+	}
+}
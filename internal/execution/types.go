@@ -25,11 +25,41 @@ type Context struct {
 	ImagePullSecret string
 
 	// Execution
-	Interpreter string
+	Language        string
+	Interpreter     string
+	InterpreterArgs []string
+	// ScriptExtension is given to the script file, e.g. ".py"
+	ScriptExtension string
 	Args        []string
 	WorkingDir  string
 	Timeout     time.Duration
 	Stdin       string
+	// Files are materialized into /workspace before the script starts
+	Files       []InputFile
+	// Inputs are the typed named inputs, exposed as OCR_INPUT_<NAME> and
+	// inputs.json
+	Inputs      map[string]interface{}
+
+	// Deadlines for the phases before the script starts
+	SchedulingTimeout time.Duration
+	ImagePullTimeout  time.Duration
+	// MaxTimeout caps how long the Job may run across all phases
+	// (security.max_timeout); zero leaves the sum of the phases uncapped
+	MaxTimeout time.Duration
+
+	// OnDisconnect is what happens to the Job when the caller goes away
+	// (OnDisconnectCancel or OnDisconnectDetach)
+	OnDisconnect string
+
+	// Output parsing
+	OutputFormat string
+	Extract      map[string]string
+
+	// SuccessCriteria replaces "exit code 0" as the success test, if set
+	SuccessCriteria *SuccessCriteria
+
+	// Artifacts are globs, relative to /workspace, of files to collect
+	Artifacts []string
 
 	// Environment
 	Env               map[string]string
@@ -46,6 +76,7 @@ type Context struct {
 	NodeSelector      map[string]string
 	Tolerations       []corev1.Toleration
 	Affinity          *corev1.Affinity
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint
 	PriorityClassName string
 
 	// Resources
@@ -57,6 +88,19 @@ type Context struct {
 	BackoffLimit            int32
 	Labels                  map[string]string
 	Annotations             map[string]string
+	// Sidecars are catalog init steps and sidecars attached to the pod
+	Sidecars                []Sidecar
+	// Tools are catalog tools copied into the pod and put on the PATH
+	Tools                   []Tool
+	// Profile is the job profile patched into the generated Job, if any
+	Profile                 string
+	// Workspace is the persistent workspace mounted as /workspace, if any
+	Workspace               *Workspace
+	// CacheTTL is how old a reused result of an identical execution may be;
+	// zero disables the result cache
+	CacheTTL                time.Duration
+	// CacheKey fingerprints the execution once the cache was consulted
+	CacheKey                string
 }
 
 // SecretKeyRef references a key in a Secret.
@@ -0,0 +1,264 @@
+package execution
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// workspaceClaimPrefix prefixes the PVC of a persistent workspace.
+	workspaceClaimPrefix = "script-ws-"
+	// workspaceLabel is set on the Jobs and pods mounting a persistent
+	// workspace, to the name of its PVC.
+	workspaceLabel = "workspace"
+	// workspaceKeyAnnotation holds the workspace key on the PVC.
+	workspaceKeyAnnotation = "workspace-key"
+	// workspaceLastUsedAnnotation holds when a step last used the workspace,
+	// in RFC 3339; the TTL runs from it.
+	workspaceLastUsedAnnotation = "workspace-last-used"
+	// maxWorkspaceKey bounds the length of a workspace key.
+	maxWorkspaceKey = 253
+)
+
+// Workspace is a persistent workspace: a PVC mounted as /workspace instead of
+// an emptyDir, which the steps of one runbook execution share.
+type Workspace struct {
+	Key string
+	// Claim is the PVC name, derived from the runbook ID and the key.
+	Claim string
+	// Release deletes the workspace once this step finishes.
+	Release bool
+}
+
+// parseWorkspace reads the workspace parameter, {persist, key, release}. The
+// key is required: every step has its own execution ID, so only a key the
+// caller chooses, usually the ID of the runbook execution, lets steps share a
+// workspace. Workspaces of different runbooks never collide.
+func parseWorkspace(params *structpb.Struct, execCtx *executorv1.ExecutionContext) (*Workspace, error) {
+	m := getMap(params, "workspace")
+	if m == nil || len(m.Fields) == 0 {
+		return nil, nil
+	}
+	var violations []*executorv1.FieldViolation
+	for name := range m.Fields {
+		switch name {
+		case "persist", "key", "release":
+		default:
+			violations = append(violations, &executorv1.FieldViolation{Field: "workspace." + name, Description: "unknown field"})
+		}
+	}
+	if !getBool(m, "persist") {
+		if len(violations) == 0 && (getString(m, "key", "") != "" || getBool(m, "release")) {
+			violations = append(violations, &executorv1.FieldViolation{Field: "workspace.persist", Description: "key and release require persist: true"})
+		}
+		if len(violations) > 0 {
+			return nil, workspaceError(violations)
+		}
+		return nil, nil
+	}
+
+	key := getString(m, "key", "")
+	switch {
+	case key == "":
+		violations = append(violations, &executorv1.FieldViolation{Field: "workspace.key", Description: "is required when persist is true"})
+	case len(key) > maxWorkspaceKey:
+		violations = append(violations, &executorv1.FieldViolation{Field: "workspace.key", Description: fmt.Sprintf("must be at most %d characters", maxWorkspaceKey)})
+	}
+	if len(violations) > 0 {
+		return nil, workspaceError(violations)
+	}
+	return &Workspace{
+		Key:     key,
+		Claim:   workspaceClaim(execCtx.GetRunbookId(), key),
+		Release: getBool(m, "release"),
+	}, nil
+}
+
+func workspaceError(violations []*executorv1.FieldViolation) *DetailedError {
+	return &DetailedError{
+		Code:       CodeInvalidParameter,
+		Message:    fmt.Sprintf("invalid workspace: %s: %s", violations[0].Field, violations[0].Description),
+		Violations: violations,
+	}
+}
+
+// workspaceClaim returns the PVC name of a runbook's workspace.
+func workspaceClaim(runbookID, key string) string {
+	h := sha256.Sum256([]byte(runbookID + "\x00" + key))
+	return workspaceClaimPrefix + hex.EncodeToString(h[:])[:20]
+}
+
+// ensureWorkspace creates the PVC of a persistent workspace, or marks an
+// existing one as used.
+func (m *Manager) ensureWorkspace(ctx context.Context, execContext *Context) error {
+	w := execContext.Workspace
+	wsCfg := m.config.ScriptExecutor.Execution.Workspaces
+	size, err := resource.ParseQuantity(wsCfg.Size)
+	if err != nil {
+		return fmt.Errorf("workspace size %q: %w", wsCfg.Size, err)
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: w.Claim,
+			Labels: map[string]string{
+				"executor":   "workspace",
+				"runbook-id": sanitizeLabel(execContext.RunbookID),
+				"managed-by": "opscontrolroom",
+			},
+			Annotations: map[string]string{
+				workspaceKeyAnnotation:      w.Key,
+				workspaceLastUsedAnnotation: time.Now().UTC().Format(time.RFC3339),
+				"runbook-id":                execContext.RunbookID,
+				"created-by":                "script-executor",
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.PersistentVolumeAccessMode(wsCfg.AccessMode)},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+	if wsCfg.StorageClass != "" {
+		pvc.Spec.StorageClassName = &wsCfg.StorageClass
+	}
+
+	claims := m.client.CoreV1().PersistentVolumeClaims(m.config.ScriptExecutor.Kubernetes.Namespace)
+	_, err = claims.Create(ctx, pvc, metav1.CreateOptions{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("create workspace: %w", err)
+	}
+	existing, err := claims.Get(ctx, w.Claim, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get workspace: %w", err)
+	}
+	if existing.Annotations[workspaceKeyAnnotation] != w.Key || existing.Annotations["runbook-id"] != execContext.RunbookID {
+		return fmt.Errorf("workspace %s belongs to another runbook execution", w.Claim)
+	}
+	if existing.DeletionTimestamp != nil {
+		return fmt.Errorf("workspace %s was released and is being deleted", w.Claim)
+	}
+	// The janitor may delete the workspace between the Get and the update;
+	// the update returns the claim as it is after the change.
+	touched, err := m.touchWorkspace(ctx, w.Claim)
+	if err != nil {
+		return err
+	}
+	if touched.DeletionTimestamp != nil {
+		return fmt.Errorf("workspace %s was released and is being deleted", w.Claim)
+	}
+	return nil
+}
+
+// touchWorkspace restarts the TTL of a workspace. The update changes the
+// claim's resourceVersion, so it also stops a janitor that listed the claim
+// before from deleting it.
+func (m *Manager) touchWorkspace(ctx context.Context, claim string) (*corev1.PersistentVolumeClaim, error) {
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				workspaceLastUsedAnnotation: time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+	})
+	pvc, err := m.client.CoreV1().PersistentVolumeClaims(m.config.ScriptExecutor.Kubernetes.Namespace).
+		Patch(ctx, claim, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("update workspace: %w", err)
+	}
+	return pvc, nil
+}
+
+// finishWorkspace deletes the workspace of a finished step that released it,
+// and restarts the TTL of any other. Kubernetes keeps a deleted PVC until the
+// step's pod is gone.
+func (m *Manager) finishWorkspace(execContext *Context) {
+	w := execContext.Workspace
+	if w == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if !w.Release {
+		if _, err := m.touchWorkspace(ctx, w.Claim); err != nil {
+			log.Printf("Failed to update workspace %s of execution %s: %v", w.Claim, execContext.ExecutionID, err)
+		}
+		return
+	}
+	err := m.client.CoreV1().PersistentVolumeClaims(m.config.ScriptExecutor.Kubernetes.Namespace).
+		Delete(ctx, w.Claim, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Printf("Failed to release workspace %s of execution %s: %v", w.Claim, execContext.ExecutionID, err)
+	}
+}
+
+// collectWorkspaces deletes the expired workspaces no unfinished Job mounts.
+// The delete is conditional on the resourceVersion the checks saw: a step that
+// starts using the workspace meanwhile updates it first, and keeps it.
+func (m *Manager) collectWorkspaces(ctx context.Context) {
+	namespace := m.config.ScriptExecutor.Kubernetes.Namespace
+	ttl := m.config.WorkspaceTTL()
+	list, err := m.client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "executor=workspace",
+	})
+	if err != nil {
+		log.Printf("list workspaces: %v", err)
+		return
+	}
+	for _, pvc := range list.Items {
+		if pvc.DeletionTimestamp != nil {
+			continue
+		}
+		lastUsed := pvc.CreationTimestamp.Time
+		if t, err := time.Parse(time.RFC3339, pvc.Annotations[workspaceLastUsedAnnotation]); err == nil {
+			lastUsed = t
+		}
+		if time.Since(lastUsed) < ttl {
+			continue
+		}
+		jobs, err := m.client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", workspaceLabel, pvc.Name),
+		})
+		if err != nil {
+			log.Printf("list jobs of workspace %s: %v", pvc.Name, err)
+			continue
+		}
+		inUse := false
+		for _, job := range jobs.Items {
+			if !isJobComplete(&job) && !isJobFailed(&job) {
+				inUse = true
+				break
+			}
+		}
+		if inUse {
+			continue
+		}
+		err = m.client.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, pvc.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{ResourceVersion: &pvc.ResourceVersion},
+		})
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			log.Printf("delete expired workspace %s: %v", pvc.Name, err)
+			continue
+		}
+		log.Printf("Deleted workspace %s, unused since %s", pvc.Name, lastUsed.Format(time.RFC3339))
+	}
+}
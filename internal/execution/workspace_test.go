@@ -0,0 +1,142 @@
+package execution
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestParseWorkspace(t *testing.T) {
+	execCtx := &executorv1.ExecutionContext{ExecutionId: "exec-1", RunbookId: "rb-1"}
+	tests := []struct {
+		name      string
+		workspace map[string]interface{}
+		wantKey   string
+		wantErr   string
+	}{
+		{name: "absent"},
+		{name: "not persisted", workspace: map[string]interface{}{"persist": false}},
+		{name: "key", workspace: map[string]interface{}{"persist": true, "key": "deploy-4711"}, wantKey: "deploy-4711"},
+		{name: "key required", workspace: map[string]interface{}{"persist": true}, wantErr: "workspace.key: is required"},
+		{name: "key too long", workspace: map[string]interface{}{"persist": true, "key": strings.Repeat("k", maxWorkspaceKey+1)}, wantErr: "workspace.key"},
+		{name: "key without persist", workspace: map[string]interface{}{"key": "deploy-4711"}, wantErr: "workspace.persist"},
+		{name: "unknown field", workspace: map[string]interface{}{"persist": true, "key": "k", "size": "1Gi"}, wantErr: "workspace.size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]interface{}{}
+			if tt.workspace != nil {
+				params["workspace"] = tt.workspace
+			}
+			w, err := parseWorkspace(mustStruct(t, params), execCtx)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseWorkspace() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantKey == "" {
+				if w != nil {
+					t.Errorf("parseWorkspace() = %+v, want none", w)
+				}
+				return
+			}
+			if w == nil || w.Key != tt.wantKey || w.Claim != workspaceClaim("rb-1", tt.wantKey) {
+				t.Errorf("parseWorkspace() = %+v, want key %q", w, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestCollectWorkspaces(t *testing.T) {
+	cfg := testConfig(t)
+	namespace := cfg.ScriptExecutor.Kubernetes.Namespace
+	expired := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	claim := func(name, lastUsed string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			ResourceVersion: "1",
+			Labels:          map[string]string{"executor": "workspace"},
+			Annotations:     map[string]string{workspaceLastUsedAnnotation: lastUsed},
+		}}
+	}
+	job := func(claim string, done bool) *batchv1.Job {
+		j := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name:      "job-" + claim,
+			Namespace: namespace,
+			Labels:    map[string]string{workspaceLabel: claim},
+		}}
+		if done {
+			j.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		}
+		return j
+	}
+	client := fake.NewSimpleClientset(
+		claim("expired", expired),
+		claim("fresh", time.Now().UTC().Format(time.RFC3339)),
+		claim("mounted", expired),
+		job("mounted", false),
+		claim("finished", expired),
+		job("finished", true),
+		claim("touched", expired),
+	)
+	// A step starts using "touched" after the janitor listed it
+	client.PrependReactor("list", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.ListAction).GetListRestrictions().Labels.String() == workspaceLabel+"=touched" {
+			ws := claim("touched", time.Now().UTC().Format(time.RFC3339))
+			ws.ResourceVersion = "2"
+			if err := client.Tracker().Update(schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, ws, namespace); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return false, nil, nil
+	})
+	// The fake client does not check delete preconditions
+	client.PrependReactor("delete", "persistentvolumeclaims", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		del := action.(k8stesting.DeleteActionImpl)
+		want := del.DeleteOptions.Preconditions
+		if want == nil || want.ResourceVersion == nil {
+			t.Errorf("delete of %s has no resourceVersion precondition", del.GetName())
+			return false, nil, nil
+		}
+		obj, err := client.Tracker().Get(del.GetResource(), del.GetNamespace(), del.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		if rv := obj.(*corev1.PersistentVolumeClaim).ResourceVersion; rv != *want.ResourceVersion {
+			return true, nil, apierrors.NewConflict(del.GetResource().GroupResource(), del.GetName(), nil)
+		}
+		return false, nil, nil
+	})
+
+	m := &Manager{config: cfg, client: client}
+	m.collectWorkspaces(context.Background())
+
+	for name, wantKept := range map[string]bool{
+		"expired":  false,
+		"fresh":    true,
+		"mounted":  true,
+		"finished": false,
+		"touched":  true,
+	} {
+		_, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if kept := err == nil; kept != wantKept {
+			t.Errorf("%s kept = %v, want %v", name, kept, wantKept)
+		}
+	}
+}
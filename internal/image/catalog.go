@@ -25,6 +25,8 @@ type Catalog struct {
 	namespace string
 	name      string
 	entries   map[string]CatalogEntry
+	sidecars  map[string]SidecarEntry
+	tools     map[string]ToolEntry
 }
 
 // NewCatalog creates an image catalog.
@@ -34,10 +36,14 @@ func NewCatalog(client kubernetes.Interface, namespace, configMapName string) *C
 		namespace: namespace,
 		name:      configMapName,
 		entries:   make(map[string]CatalogEntry),
+		sidecars:  make(map[string]SidecarEntry),
+		tools:     make(map[string]ToolEntry),
 	}
 }
 
-// Load fetches and parses the catalog from the ConfigMap.
+// Load fetches and parses the catalog from the ConfigMap: images from
+// catalog.yaml, init containers and sidecars from the optional sidecars.yaml,
+// and tools from the optional tools.yaml.
 func (c *Catalog) Load(ctx context.Context) error {
 	cm, err := c.client.CoreV1().ConfigMaps(c.namespace).Get(ctx, c.name, metav1.GetOptions{})
 	if err != nil {
@@ -54,7 +60,23 @@ func (c *Catalog) Load(ctx context.Context) error {
 		return fmt.Errorf("parse catalog.yaml: %w", err)
 	}
 
+	sidecars := make(map[string]SidecarEntry)
+	if sidecarYAML, ok := cm.Data["sidecars.yaml"]; ok {
+		if err := yaml.Unmarshal([]byte(sidecarYAML), &sidecars); err != nil {
+			return fmt.Errorf("parse sidecars.yaml: %w", err)
+		}
+	}
+
+	tools := make(map[string]ToolEntry)
+	if toolYAML, ok := cm.Data["tools.yaml"]; ok {
+		if err := yaml.Unmarshal([]byte(toolYAML), &tools); err != nil {
+			return fmt.Errorf("parse tools.yaml: %w", err)
+		}
+	}
+
 	c.entries = data
+	c.sidecars = sidecars
+	c.tools = tools
 	return nil
 }
 
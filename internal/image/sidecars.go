@@ -0,0 +1,39 @@
+package image
+
+import "fmt"
+
+// SidecarEntry describes a container from the sidecar catalog that a request
+// can attach to the script's pod by name.
+type SidecarEntry struct {
+	Image       string `yaml:"image"`
+	PullSecret  string `yaml:"pull_secret"`
+	Description string `yaml:"description"`
+	// Init entries run to completion before the script starts. Other entries
+	// are native sidecars: they start before the script, keep running next to
+	// it, and are stopped when it exits.
+	Init      bool              `yaml:"init"`
+	Command   []string          `yaml:"command"`
+	Args      []string          `yaml:"args"`
+	Env       map[string]string `yaml:"env"`
+	Resources SidecarResources  `yaml:"resources"`
+}
+
+// SidecarResources are Kubernetes quantities by resource name, e.g.
+// {cpu: 50m, memory: 32Mi}.
+type SidecarResources struct {
+	Requests map[string]string `yaml:"requests"`
+	Limits   map[string]string `yaml:"limits"`
+}
+
+// Sidecar returns the sidecar catalog entry with the given name. The catalog
+// must have been loaded.
+func (c *Catalog) Sidecar(name string) (SidecarEntry, error) {
+	entry, ok := c.sidecars[name]
+	if !ok {
+		return SidecarEntry{}, fmt.Errorf("sidecar %q not found in catalog", name)
+	}
+	if entry.Image == "" {
+		return SidecarEntry{}, fmt.Errorf("sidecar %q has no image", name)
+	}
+	return entry, nil
+}
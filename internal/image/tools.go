@@ -0,0 +1,37 @@
+package image
+
+import "fmt"
+
+// ToolEntry describes a tool from the tool catalog: files copied out of a
+// source image into the script's pod, so tools can be combined without
+// building an image per combination.
+type ToolEntry struct {
+	Image       string `yaml:"image"`
+	PullSecret  string `yaml:"pull_secret"`
+	Description string `yaml:"description"`
+	// Paths are the files or directories copied, with symlinks followed,
+	// into /opt/tools/<name>/.
+	Paths []string `yaml:"paths"`
+	// Bin are the directories, relative to /opt/tools/<name>, put on the
+	// script's PATH. Empty means /opt/tools/<name> itself.
+	Bin []string `yaml:"bin"`
+	// Command replaces the default copy command ("cp -RL <paths>
+	// /opt/tools/<name>/") for images without cp.
+	Command []string `yaml:"command"`
+}
+
+// Tool returns the tool catalog entry with the given name. The catalog must
+// have been loaded.
+func (c *Catalog) Tool(name string) (ToolEntry, error) {
+	entry, ok := c.tools[name]
+	if !ok {
+		return ToolEntry{}, fmt.Errorf("tool %q not found in catalog", name)
+	}
+	if entry.Image == "" {
+		return ToolEntry{}, fmt.Errorf("tool %q has no image", name)
+	}
+	if len(entry.Paths) == 0 && len(entry.Command) == 0 {
+		return ToolEntry{}, fmt.Errorf("tool %q has no paths", name)
+	}
+	return entry, nil
+}
@@ -0,0 +1,149 @@
+package logstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when no log exists for a reference.
+var ErrNotFound = errors.New("log not found")
+
+// Store keeps full execution logs that were too large to return inline.
+type Store interface {
+	// Create opens a new log for writing and returns its reference.
+	Create(ctx context.Context, name string) (io.WriteCloser, string, error)
+	// Open returns the log with the given reference.
+	Open(ctx context.Context, ref string) (io.ReadCloser, error)
+	// Delete removes a log. Deleting a missing log is not an error.
+	Delete(ctx context.Context, ref string) error
+	// Prune deletes the logs written before the given time, then the oldest
+	// logs until the rest take at most maxBytes (no limit if zero). It returns
+	// how many logs it deleted.
+	Prune(ctx context.Context, before time.Time, maxBytes int64) (int, error)
+}
+
+// New returns the store for a backend type. An empty type or "none" disables
+// log storage and returns nil.
+func New(storeType, path string) (Store, error) {
+	switch storeType {
+	case "", "none":
+		return nil, nil
+	case "filesystem":
+		return NewFilesystemStore(path)
+	}
+	return nil, fmt.Errorf("unknown log store type %q", storeType)
+}
+
+// FilesystemStore implements Store with one file per log in a directory.
+// References are file names relative to that directory.
+type FilesystemStore struct {
+	dir string
+}
+
+// NewFilesystemStore creates a filesystem log store, creating dir if needed.
+func NewFilesystemStore(dir string) (*FilesystemStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	return &FilesystemStore{dir: dir}, nil
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// Create implements Store.
+func (s *FilesystemStore) Create(ctx context.Context, name string) (io.WriteCloser, string, error) {
+	ref := unsafeChars.ReplaceAllString(name, "_") + ".log"
+	f, err := os.OpenFile(filepath.Join(s.dir, ref), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, "", fmt.Errorf("create log: %w", err)
+	}
+	return f, ref, nil
+}
+
+// Open implements Store.
+func (s *FilesystemStore) Open(ctx context.Context, ref string) (io.ReadCloser, error) {
+	path, err := s.path(ref)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open log: %w", err)
+	}
+	return f, nil
+}
+
+// Delete implements Store.
+func (s *FilesystemStore) Delete(ctx context.Context, ref string) error {
+	path, err := s.path(ref)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete log: %w", err)
+	}
+	return nil
+}
+
+// Prune implements Store, by file modification time.
+func (s *FilesystemStore) Prune(ctx context.Context, before time.Time, maxBytes int64) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("list logs: %w", err)
+	}
+	type logFile struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+	var files []logFile
+	var total int64
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			// Deleted since the listing
+			continue
+		}
+		files = append(files, logFile{name: e.Name(), size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	deleted := 0
+	for _, f := range files {
+		if !f.modTime.Before(before) && (maxBytes <= 0 || total <= maxBytes) {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		if err := os.Remove(filepath.Join(s.dir, f.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return deleted, fmt.Errorf("delete log: %w", err)
+		}
+		total -= f.size
+		deleted++
+	}
+	return deleted, nil
+}
+
+// path resolves a reference, rejecting anything outside the store directory.
+func (s *FilesystemStore) path(ref string) (string, error) {
+	if ref == "" || strings.ContainsAny(ref, `/\`) || ref != filepath.Base(ref) || strings.HasPrefix(ref, ".") {
+		return "", ErrNotFound
+	}
+	return filepath.Join(s.dir, ref), nil
+}
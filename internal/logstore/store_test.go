@@ -0,0 +1,75 @@
+package logstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeLog(t *testing.T, s *FilesystemStore, name string, size int, age time.Duration) string {
+	t.Helper()
+	w, ref, err := s.Create(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(strings.Repeat("x", size))); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(filepath.Join(s.dir, ref), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		maxBytes int64
+		want     []string
+	}{
+		{name: "retention only", maxBytes: 0, want: []string{"mid.log", "new.log"}},
+		{name: "size limit drops oldest", maxBytes: 150, want: []string{"new.log"}},
+		{name: "size limit already met", maxBytes: 1000, want: []string{"mid.log", "new.log"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewFilesystemStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeLog(t, s, "old", 100, 48*time.Hour)
+			writeLog(t, s, "mid", 100, 2*time.Hour)
+			writeLog(t, s, "new", 100, time.Minute)
+
+			n, err := s.Prune(ctx, time.Now().Add(-24*time.Hour), tt.maxBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 3-len(tt.want) {
+				t.Errorf("Prune deleted %d logs, want %d", n, 3-len(tt.want))
+			}
+			for _, ref := range []string{"old.log", "mid.log", "new.log"} {
+				r, err := s.Open(ctx, ref)
+				kept := err == nil
+				if kept {
+					r.Close()
+				}
+				wantKept := false
+				for _, w := range tt.want {
+					wantKept = wantKept || w == ref
+				}
+				if kept != wantKept {
+					t.Errorf("%s kept = %v, want %v", ref, kept, wantKept)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package record
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// ErrNotFound is returned when no record exists for an execution ID.
+var ErrNotFound = errors.New("execution record not found")
+
+// State represents the lifecycle state of an execution.
+type State string
+
+const (
+	StatePending   State = "pending"
+	StateRunning   State = "running"
+	StateSucceeded State = "succeeded"
+	StateFailed    State = "failed"
+	StateTimeout   State = "timeout"
+	StateCancelled State = "cancelled"
+)
+
+// Terminal reports whether the state is final.
+func (s State) Terminal() bool {
+	switch s {
+	case StateSucceeded, StateFailed, StateTimeout, StateCancelled:
+		return true
+	}
+	return false
+}
+
+// Record is the persisted state of a single execution.
+type Record struct {
+	ExecutionID string `json:"execution_id"`
+	RunbookID   string `json:"runbook_id,omitempty"`
+	User        string `json:"user,omitempty"`
+	State       State  `json:"state"`
+	JobName     string `json:"job_name,omitempty"`
+	PodName     string `json:"pod_name,omitempty"`
+	ScriptHash  string `json:"script_hash,omitempty"`
+	Image       string `json:"image,omitempty"`
+	// OutputParams holds the request parameters the result is checked against
+	// (output_format, extract, success_criteria, artifacts), so any replica
+	// can finish an execution whose monitor went away.
+	OutputParams json.RawMessage `json:"output_params,omitempty"`
+	// Result is the protojson-encoded ExecuteResponse, set once the execution is terminal.
+	Result       json.RawMessage `json:"result,omitempty"`
+	CancelReason string          `json:"cancel_reason,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	FinishedAt   time.Time       `json:"finished_at,omitempty"`
+	// ResourceVersion is the version the record was read at. Saving a record
+	// with a version fails with a conflict if it changed since.
+	ResourceVersion string `json:"-"`
+}
+
+// Store provides execution record persistence.
+type Store interface {
+	// Save creates or replaces the record for rec.ExecutionID. A record with
+	// a ResourceVersion only replaces that version; otherwise Save returns a
+	// conflict error (apierrors.IsConflict).
+	Save(ctx context.Context, rec *Record) error
+	Get(ctx context.Context, executionID string) (*Record, error)
+	// List returns the records in the given state.
+	List(ctx context.Context, state State) ([]*Record, error)
+	// Prune deletes the records of executions that finished, or were
+	// created, before the given time, and returns how many it deleted.
+	Prune(ctx context.Context, before time.Time) (int, error)
+}
+
+// Update reads the record for an execution, applies mutate and saves it,
+// retrying when the record was changed in between. When mutate returns false
+// the record is left as read. Update returns the record as last read or saved.
+func Update(ctx context.Context, s Store, executionID string, mutate func(*Record) bool) (*Record, error) {
+	var rec *Record
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		if rec, err = s.Get(ctx, executionID); err != nil {
+			return err
+		}
+		if !mutate(rec) {
+			return nil
+		}
+		return s.Save(ctx, rec)
+	})
+	return rec, err
+}
+
+// ConfigMapStore implements Store with one ConfigMap per execution, so records
+// outlive both the gRPC request and the Job's TTL.
+type ConfigMapStore struct {
+	client    kubernetes.Interface
+	namespace string
+	prefix    string
+}
+
+// NewConfigMapStore creates a ConfigMap-backed record store.
+func NewConfigMapStore(client kubernetes.Interface, namespace, prefix string) *ConfigMapStore {
+	return &ConfigMapStore{
+		client:    client,
+		namespace: namespace,
+		prefix:    prefix,
+	}
+}
+
+const recordKey = "record.json"
+
+func (s *ConfigMapStore) name(executionID string) string {
+	return fmt.Sprintf("%s-%s", s.prefix, executionID)
+}
+
+// Save creates or replaces the record for rec.ExecutionID.
+func (s *ConfigMapStore) Save(ctx context.Context, rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	cms := s.client.CoreV1().ConfigMaps(s.namespace)
+	var cm *corev1.ConfigMap
+	if rec.ResourceVersion == "" {
+		cm, err = cms.Get(ctx, s.name(rec.ExecutionID), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      s.name(rec.ExecutionID),
+					Namespace: s.namespace,
+					Labels: map[string]string{
+						"executor":   "script",
+						"managed-by": "opscontrolroom",
+						"component":  "execution-record",
+					},
+					Annotations: map[string]string{
+						"execution-id": rec.ExecutionID,
+					},
+				},
+				Data: map[string]string{recordKey: string(data)},
+			}
+			created, err := cms.Create(ctx, cm, metav1.CreateOptions{})
+			if err != nil {
+				return err
+			}
+			rec.ResourceVersion = created.ResourceVersion
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		// Only the version the caller read may be replaced
+		cm, err = cms.Get(ctx, s.name(rec.ExecutionID), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		cm.ResourceVersion = rec.ResourceVersion
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[recordKey] = string(data)
+	updated, err := cms.Update(ctx, cm, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	rec.ResourceVersion = updated.ResourceVersion
+	return nil
+}
+
+// Get retrieves the record for an execution.
+func (s *ConfigMapStore) Get(ctx context.Context, executionID string) (*Record, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name(executionID), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, ok := cm.Data[recordKey]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var rec Record
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		return nil, fmt.Errorf("parse execution record: %w", err)
+	}
+	rec.ResourceVersion = cm.ResourceVersion
+	return &rec, nil
+}
+
+// List returns the records in the given state. Unreadable records are skipped.
+func (s *ConfigMapStore) List(ctx context.Context, state State) ([]*Record, error) {
+	list, err := s.client.CoreV1().ConfigMaps(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: "component=execution-record"})
+	if err != nil {
+		return nil, err
+	}
+	var records []*Record
+	for _, cm := range list.Items {
+		var rec Record
+		if err := json.Unmarshal([]byte(cm.Data[recordKey]), &rec); err != nil || rec.State != state {
+			continue
+		}
+		rec.ResourceVersion = cm.ResourceVersion
+		records = append(records, &rec)
+	}
+	return records, nil
+}
+
+// Prune deletes the record ConfigMaps of executions that finished before
+// the given time, and of unfinished ones created before it.
+func (s *ConfigMapStore) Prune(ctx context.Context, before time.Time) (int, error) {
+	cms := s.client.CoreV1().ConfigMaps(s.namespace)
+	list, err := cms.List(ctx, metav1.ListOptions{LabelSelector: "component=execution-record"})
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, cm := range list.Items {
+		var rec Record
+		if err := json.Unmarshal([]byte(cm.Data[recordKey]), &rec); err != nil {
+			// An unreadable record is aged by its ConfigMap
+			rec.CreatedAt = cm.CreationTimestamp.Time
+		}
+		last := rec.CreatedAt
+		if !rec.FinishedAt.IsZero() {
+			last = rec.FinishedAt
+		}
+		if !last.Before(before) {
+			continue
+		}
+		err := cms.Delete(ctx, cm.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{ResourceVersion: &cm.ResourceVersion},
+		})
+		if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+			return deleted, err
+		}
+		if err == nil {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,122 @@
+package record
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// conflictStore fails the first saves with a conflict, as if another writer
+// updated the record in between.
+type conflictStore struct {
+	Store
+	conflicts int
+	saved     []State
+}
+
+func (s *conflictStore) Save(ctx context.Context, rec *Record) error {
+	if s.conflicts > 0 {
+		s.conflicts--
+		return apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, rec.ExecutionID, nil)
+	}
+	s.saved = append(s.saved, rec.State)
+	return s.Store.Save(ctx, rec)
+}
+
+func TestUpdateRetriesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	store := &conflictStore{Store: NewConfigMapStore(fake.NewSimpleClientset(), "ns", "rec"), conflicts: 2}
+	if err := store.Store.Save(ctx, &Record{ExecutionID: "e1", State: StateRunning}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	rec, err := Update(ctx, store, "e1", func(rec *Record) bool {
+		calls++
+		rec.State = StateSucceeded
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if calls != 3 || rec.State != StateSucceeded || len(store.saved) != 1 {
+		t.Errorf("calls = %d, state = %s, saves = %v; want 3 calls and one succeeded save", calls, rec.State, store.saved)
+	}
+}
+
+func TestUpdateLeavesRecordWhenMutateDeclines(t *testing.T) {
+	ctx := context.Background()
+	store := &conflictStore{Store: NewConfigMapStore(fake.NewSimpleClientset(), "ns", "rec")}
+	if err := store.Store.Save(ctx, &Record{ExecutionID: "e1", State: StateCancelled}); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := Update(ctx, store, "e1", func(rec *Record) bool {
+		return rec.State != StateCancelled
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.State != StateCancelled || len(store.saved) != 0 {
+		t.Errorf("state = %s, saves = %v; want the cancelled record untouched", rec.State, store.saved)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	store := NewConfigMapStore(fake.NewSimpleClientset(), "ns", "rec")
+	now := time.Now()
+	records := []*Record{
+		{ExecutionID: "old-finished", State: StateSucceeded, CreatedAt: now.Add(-10 * 24 * time.Hour), FinishedAt: now.Add(-9 * 24 * time.Hour)},
+		{ExecutionID: "recent-finished", State: StateFailed, CreatedAt: now.Add(-10 * 24 * time.Hour), FinishedAt: now.Add(-time.Hour)},
+		{ExecutionID: "old-pending", State: StatePending, CreatedAt: now.Add(-8 * 24 * time.Hour)},
+		{ExecutionID: "running", State: StateRunning, CreatedAt: now.Add(-time.Minute)},
+	}
+	for _, rec := range records {
+		if err := store.Save(ctx, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := store.Prune(ctx, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Prune deleted %d records, want 2", n)
+	}
+	for _, id := range []string{"old-finished", "old-pending"} {
+		if _, err := store.Get(ctx, id); err != ErrNotFound {
+			t.Errorf("Get(%s) = %v, want ErrNotFound", id, err)
+		}
+	}
+	for _, id := range []string{"recent-finished", "running"} {
+		if _, err := store.Get(ctx, id); err != nil {
+			t.Errorf("Get(%s) = %v, want the record kept", id, err)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	store := NewConfigMapStore(fake.NewSimpleClientset(), "ns", "rec")
+	for id, state := range map[string]State{"e1": StateRunning, "e2": StateSucceeded, "e3": StateRunning, "e4": StatePending} {
+		if err := store.Save(ctx, &Record{ExecutionID: id, State: state}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, err := store.List(ctx, StateRunning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, rec := range records {
+		got[rec.ExecutionID] = true
+	}
+	if len(got) != 2 || !got["e1"] || !got["e3"] {
+		t.Errorf("List(running) = %v, want e1 and e3", got)
+	}
+}
@@ -0,0 +1,147 @@
+package script
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// MaxBundleSize bounds the unpacked size of a bundle. Bundles are delivered
+// to the Job through a Secret, which must stay under 1MB.
+const MaxBundleSize = 768 * 1024
+
+// BundleFile is one file of a bundle.
+type BundleFile struct {
+	// Path is relative to the bundle root.
+	Path       string
+	Content    []byte
+	Executable bool
+}
+
+// Bundle is a multi-file script: an entrypoint plus the helper files it uses,
+// unpacked together into the workspace.
+type Bundle struct {
+	Entrypoint string
+	Files      []BundleFile
+	// Hash covers every path, mode and content, and the entrypoint.
+	Hash string
+}
+
+// NewBundle validates the files and entrypoint and computes the bundle hash.
+func NewBundle(entrypoint string, files []BundleFile) (*Bundle, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("bundle has no files")
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	total := 0
+	for i, f := range files {
+		if err := checkBundlePath(f.Path); err != nil {
+			return nil, err
+		}
+		if i > 0 && files[i-1].Path == f.Path {
+			return nil, fmt.Errorf("bundle path %q appears twice", f.Path)
+		}
+		total += len(f.Content)
+	}
+	if total > MaxBundleSize {
+		return nil, fmt.Errorf("bundle too large: %d bytes (max: %d)", total, MaxBundleSize)
+	}
+	b := &Bundle{Entrypoint: entrypoint, Files: files}
+	if b.File(entrypoint) == nil {
+		return nil, fmt.Errorf("bundle entrypoint %q not found", entrypoint)
+	}
+	b.Hash = bundleHash(entrypoint, files)
+	return b, nil
+}
+
+// File returns the file at p, or nil.
+func (b *Bundle) File(p string) *BundleFile {
+	for i := range b.Files {
+		if b.Files[i].Path == p {
+			return &b.Files[i]
+		}
+	}
+	return nil
+}
+
+// bundleHash is a Merkle-style hash: every file is hashed on its own, and the
+// root hash covers the sorted list of "<mode> <path> <file hash>" lines
+// followed by the entrypoint. Changing, adding, removing or renaming any file,
+// or changing the entrypoint, changes the hash.
+func bundleHash(entrypoint string, files []BundleFile) string {
+	root := sha256.New()
+	for _, f := range files {
+		leaf := sha256.Sum256(f.Content)
+		mode := "file"
+		if f.Executable {
+			mode = "exec"
+		}
+		fmt.Fprintf(root, "%s %s %s\n", mode, f.Path, hex.EncodeToString(leaf[:]))
+	}
+	fmt.Fprintf(root, "entrypoint %s\n", entrypoint)
+	return hex.EncodeToString(root.Sum(nil))
+}
+
+// checkBundlePath rejects paths that would escape the bundle root.
+func checkBundlePath(p string) error {
+	if p == "" || strings.HasPrefix(p, "/") || path.Clean(p) != p || p == ".." || strings.HasPrefix(p, "../") {
+		return fmt.Errorf("invalid bundle path %q", p)
+	}
+	if strings.ContainsAny(p, " \t\r\n") {
+		return fmt.Errorf("bundle path %q must not contain whitespace", p)
+	}
+	return nil
+}
+
+// unpackArchive reads the regular files of a tar archive, gzip-compressed or
+// not. Directories are implied by file paths; anything else is rejected.
+func unpackArchive(data []byte) ([]BundleFile, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("read gzip: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var files []BundleFile
+	total := int64(0)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tar: %w", err)
+		}
+		name := strings.TrimPrefix(hdr.Name, "./")
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+		default:
+			return nil, fmt.Errorf("bundle entry %q is not a regular file", hdr.Name)
+		}
+		total += hdr.Size
+		if total > MaxBundleSize {
+			return nil, fmt.Errorf("bundle too large: more than %d bytes", MaxBundleSize)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", hdr.Name, err)
+		}
+		files = append(files, BundleFile{Path: name, Content: content, Executable: hdr.Mode&0o111 != 0})
+	}
+	return files, nil
+}
@@ -0,0 +1,218 @@
+package script
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	content  string
+	linkname string
+}
+
+func makeTar(t *testing.T, gz bool, entries ...tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var tw *tar.Writer
+	var zw *gzip.Writer
+	if gz {
+		zw = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(zw)
+	} else {
+		tw = tar.NewWriter(&buf)
+	}
+	for _, e := range entries {
+		mode := e.mode
+		if mode == 0 {
+			mode = 0o644
+		}
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Mode: mode, Linkname: e.linkname}
+		if e.typeflag == tar.TypeReg {
+			hdr.Size = int64(len(e.content))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if e.typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestCheckBundlePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"main.sh", false},
+		{"lib/common.sh", false},
+		{"", true},
+		{"/etc/passwd", true},
+		{"..", true},
+		{"../main.sh", true},
+		{"lib/../../main.sh", true},
+		{"lib/./common.sh", true},
+		{"lib//common.sh", true},
+		{"lib/", true},
+		{"my script.sh", true},
+	}
+	for _, tt := range tests {
+		if err := checkBundlePath(tt.path); (err != nil) != tt.wantErr {
+			t.Errorf("checkBundlePath(%q) = %v, want error %v", tt.path, err, tt.wantErr)
+		}
+	}
+}
+
+func TestUnpackArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		gz      bool
+		entries []tarEntry
+		want    []BundleFile
+		wantErr string
+	}{
+		{
+			name: "plain tar",
+			entries: []tarEntry{
+				{name: "./", typeflag: tar.TypeDir},
+				{name: "./main.sh", typeflag: tar.TypeReg, mode: 0o755, content: "echo hi"},
+				{name: "lib/", typeflag: tar.TypeDir},
+				{name: "lib/common.sh", typeflag: tar.TypeReg, content: "x=1"},
+			},
+			want: []BundleFile{
+				{Path: "main.sh", Content: []byte("echo hi"), Executable: true},
+				{Path: "lib/common.sh", Content: []byte("x=1")},
+			},
+		},
+		{
+			name:    "gzip",
+			gz:      true,
+			entries: []tarEntry{{name: "main.sh", typeflag: tar.TypeReg, content: "echo hi"}},
+			want:    []BundleFile{{Path: "main.sh", Content: []byte("echo hi")}},
+		},
+		{
+			name:    "symlink",
+			entries: []tarEntry{{name: "passwd", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}},
+			wantErr: "is not a regular file",
+		},
+		{
+			name:    "hard link",
+			entries: []tarEntry{{name: "passwd", typeflag: tar.TypeLink, linkname: "/etc/passwd"}},
+			wantErr: "is not a regular file",
+		},
+		{
+			name:    "too large",
+			entries: []tarEntry{{name: "big", typeflag: tar.TypeReg, content: strings.Repeat("x", MaxBundleSize+1)}},
+			wantErr: "bundle too large",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := unpackArchive(makeTar(t, tt.gz, tt.entries...))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("unpackArchive() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != len(tt.want) {
+				t.Fatalf("unpackArchive() = %d files, want %d", len(files), len(tt.want))
+			}
+			for i, f := range files {
+				w := tt.want[i]
+				if f.Path != w.Path || string(f.Content) != string(w.Content) || f.Executable != w.Executable {
+					t.Errorf("file %d = %+v, want %+v", i, f, w)
+				}
+			}
+		})
+	}
+}
+
+func TestNewBundleRejectsTraversal(t *testing.T) {
+	// unpackArchive keeps the archive's names; NewBundle must reject them
+	files, err := unpackArchive(makeTar(t, false,
+		tarEntry{name: "main.sh", typeflag: tar.TypeReg, content: "echo hi"},
+		tarEntry{name: "../../home/user/.bashrc", typeflag: tar.TypeReg, content: "evil"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBundle("main.sh", files); err == nil || !strings.Contains(err.Error(), "invalid bundle path") {
+		t.Errorf("NewBundle() error = %v, want an invalid bundle path", err)
+	}
+}
+
+func TestNewBundle(t *testing.T) {
+	file := func(p, content string) BundleFile { return BundleFile{Path: p, Content: []byte(content)} }
+	tests := []struct {
+		name       string
+		entrypoint string
+		files      []BundleFile
+		wantErr    string
+	}{
+		{name: "valid", entrypoint: "main.sh", files: []BundleFile{file("main.sh", "a"), file("lib/x.sh", "b")}},
+		{name: "no files", entrypoint: "main.sh", wantErr: "no files"},
+		{name: "missing entrypoint", entrypoint: "run.sh", files: []BundleFile{file("main.sh", "a")}, wantErr: "not found"},
+		{name: "duplicate path", entrypoint: "main.sh", files: []BundleFile{file("main.sh", "a"), file("main.sh", "b")}, wantErr: "appears twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewBundle(tt.entrypoint, tt.files)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("NewBundle() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBundleHash(t *testing.T) {
+	base := []BundleFile{
+		{Path: "lib/x.sh", Content: []byte("x=1")},
+		{Path: "main.sh", Content: []byte("echo hi"), Executable: true},
+	}
+	baseHash := bundleHash("main.sh", base)
+	if bundleHash("main.sh", append([]BundleFile(nil), base...)) != baseHash {
+		t.Fatal("bundleHash is not deterministic")
+	}
+	tests := []struct {
+		name       string
+		entrypoint string
+		mutate     func([]BundleFile) []BundleFile
+	}{
+		{"content", "main.sh", func(f []BundleFile) []BundleFile { f[0].Content = []byte("x=2"); return f }},
+		{"mode", "main.sh", func(f []BundleFile) []BundleFile { f[0].Executable = true; return f }},
+		{"rename", "main.sh", func(f []BundleFile) []BundleFile { f[0].Path = "lib/y.sh"; return f }},
+		{"added file", "main.sh", func(f []BundleFile) []BundleFile { return append(f, BundleFile{Path: "z"}) }},
+		{"removed file", "main.sh", func(f []BundleFile) []BundleFile { return f[1:] }},
+		{"entrypoint", "lib/x.sh", func(f []BundleFile) []BundleFile { return f }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := make([]BundleFile, len(base))
+			copy(files, base)
+			if got := bundleHash(tt.entrypoint, tt.mutate(files)); got == baseHash {
+				t.Errorf("bundleHash did not change")
+			}
+		})
+	}
+}
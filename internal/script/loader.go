@@ -27,6 +27,7 @@ const (
 	SourceSecret     SourceType = "secret"
 	SourcePath       SourceType = "path"
 	SourceRegistry   SourceType = "registry"
+	SourceBundle     SourceType = "bundle"
 )
 
 // Source describes the script source for audit/logging.
@@ -34,9 +35,12 @@ type Source struct {
 	Type      SourceType
 	Content   string   // For inline/configmap/secret: the script content
 	Path      string   // For path: the path (e.g. /scripts/foo.sh)
+	Version   string   // For path: resourceVersion of the approved-scripts ConfigMap
 	Name      string   // ConfigMap or Secret name
 	Key       string   // Key within ConfigMap/Secret
 	Namespace string   // K8s namespace
+	Bundle    *Bundle  // For bundles (script_bundle, or a registry bundle entry)
+	Inputs    map[string]InputSpec // Inputs declared by a registry entry
 }
 
 // Loader loads scripts from various sources.
@@ -145,7 +149,7 @@ func (l *Loader) LoadScript(ctx context.Context, params *structpb.Struct) (strin
 
 	// 4. Script path (pre-mounted)
 	if path := getString(params, "script_path", ""); path != "" {
-		exists, err := l.scriptPathExists(ctx, path)
+		version, exists, err := l.scriptPathVersion(ctx, path)
 		if err != nil {
 			return "", nil, fmt.Errorf("validate script path: %w", err)
 		}
@@ -153,10 +157,34 @@ func (l *Loader) LoadScript(ctx context.Context, params *structpb.Struct) (strin
 			return "", nil, fmt.Errorf("script not found in approved-scripts: %s", path)
 		}
 		// Empty content - script runs from mounted path
-		return "", &Source{Type: SourcePath, Path: path}, nil
+		return "", &Source{Type: SourcePath, Path: path, Version: version}, nil
 	}
 
-	// 5. Script by registry ID
+	// 5. Bundle from a ConfigMap archive
+	if bundleRef := getMap(params, "script_bundle"); bundleRef != nil && len(bundleRef.Fields) > 0 {
+		cmName := getString(bundleRef, "configmap_name", "")
+		key := getString(bundleRef, "key", "")
+		entrypoint := getString(bundleRef, "entrypoint", "")
+		if cmName == "" || key == "" || entrypoint == "" {
+			return "", nil, fmt.Errorf("script_bundle requires configmap_name, key and entrypoint")
+		}
+		ns := getString(bundleRef, "namespace", l.namespace)
+		bundle, err := l.loadBundle(ctx, ns, cmName, key, entrypoint)
+		if err != nil {
+			return "", nil, fmt.Errorf("load bundle: %w", err)
+		}
+		content := string(bundle.File(entrypoint).Content)
+		return content, &Source{
+			Type:      SourceBundle,
+			Content:   content,
+			Name:      cmName,
+			Key:       key,
+			Namespace: ns,
+			Bundle:    bundle,
+		}, nil
+	}
+
+	// 6. Script by registry ID
 	if scriptID := getString(params, "script_id", ""); scriptID != "" {
 		content, source, err := l.registry.LoadByID(ctx, scriptID)
 		if err != nil {
@@ -165,7 +193,29 @@ func (l *Loader) LoadScript(ctx context.Context, params *structpb.Struct) (strin
 		return content, source, nil
 	}
 
-	return "", nil, fmt.Errorf("no script source provided (inline_script, script_from_configmap, script_from_secret, script_path, or script_id)")
+	return "", nil, fmt.Errorf("no script source provided (inline_script, script_from_configmap, script_from_secret, script_path, script_bundle, or script_id)")
+}
+
+// loadBundle unpacks a tar or tar.gz archive stored in a ConfigMap key,
+// usually in binaryData.
+func (l *Loader) loadBundle(ctx context.Context, namespace, name, key, entrypoint string) (*Bundle, error) {
+	cm, err := l.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, ok := cm.BinaryData[key]
+	if !ok {
+		text, ok := cm.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found in configmap %s/%s", key, namespace, name)
+		}
+		data = []byte(text)
+	}
+	files, err := unpackArchive(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewBundle(entrypoint, files)
 }
 
 func (l *Loader) loadFromConfigMap(ctx context.Context, namespace, name, key string) (string, error) {
@@ -192,23 +242,24 @@ func (l *Loader) loadFromSecret(ctx context.Context, namespace, name, key string
 	return string(contentBytes), nil
 }
 
-// scriptPathExists checks if the path exists in the approved-scripts ConfigMap.
-// Path should be like /scripts/foo.sh - we validate the key (filename) exists.
-func (l *Loader) scriptPathExists(ctx context.Context, path string) (bool, error) {
+// scriptPathVersion checks if the path exists in the approved-scripts
+// ConfigMap, and returns the ConfigMap's resourceVersion. Path should be like
+// /scripts/foo.sh - we validate the key (filename) exists.
+func (l *Loader) scriptPathVersion(ctx context.Context, path string) (string, bool, error) {
 	// Path format: /scripts/filename.sh
 	// ConfigMap keys are the filenames
 	if len(path) < 9 || path[:9] != "/scripts/" {
-		return false, fmt.Errorf("script_path must start with /scripts/")
+		return "", false, fmt.Errorf("script_path must start with /scripts/")
 	}
 	key := path[9:]
 	if key == "" {
-		return false, fmt.Errorf("script_path must include filename after /scripts/")
+		return "", false, fmt.Errorf("script_path must include filename after /scripts/")
 	}
 
 	cm, err := l.client.CoreV1().ConfigMaps(l.namespace).Get(ctx, ApprovedScriptsConfigMap, metav1.GetOptions{})
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 	_, ok := cm.Data[key]
-	return ok, nil
+	return cm.ResourceVersion, ok, nil
 }
@@ -9,11 +9,36 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// RegistryEntry describes a script in the registry.
+// RegistryEntry describes a script in the registry. A bundle entry lists its
+// files (path in the bundle to ConfigMap or Secret key) or names an archive
+// key, and declares its entrypoint.
 type RegistryEntry struct {
-	ConfigMap string `yaml:"configmap"`
-	Secret    string `yaml:"secret"`
-	Key       string `yaml:"key"`
+	ConfigMap  string            `yaml:"configmap"`
+	Secret     string            `yaml:"secret"`
+	Key        string            `yaml:"key"`
+	Files      map[string]string `yaml:"files"`
+	Executable []string          `yaml:"executable"`
+	Archive    string            `yaml:"archive"`
+	Entrypoint string            `yaml:"entrypoint"`
+	// Inputs declares the named inputs the script accepts.
+	Inputs map[string]InputSpec `yaml:"inputs"`
+}
+
+// InputSpec declares a named script input.
+type InputSpec struct {
+	// Type is string, int, bool, enum or list.
+	Type string `yaml:"type"`
+	// Values are the allowed values of an enum.
+	Values []string `yaml:"values"`
+	// Items is the element type of a list: string (default), int or bool.
+	Items    string      `yaml:"items"`
+	Required bool        `yaml:"required"`
+	Default  interface{} `yaml:"default"`
+}
+
+// isBundle reports whether the entry describes a bundle.
+func (e *RegistryEntry) isBundle() bool {
+	return len(e.Files) > 0 || e.Archive != ""
 }
 
 // RegistryData is the parsed registry.yaml structure.
@@ -43,6 +68,19 @@ func (r *Registry) LoadByID(ctx context.Context, scriptID string) (string, *Sour
 	if err != nil {
 		return "", nil, err
 	}
+	content, source, err := r.load(ctx, scriptID, entry)
+	if err != nil {
+		return "", nil, err
+	}
+	source.Inputs = entry.Inputs
+	return content, source, nil
+}
+
+// load loads the script or bundle of a registry entry.
+func (r *Registry) load(ctx context.Context, scriptID string, entry *RegistryEntry) (string, *Source, error) {
+	if entry.isBundle() {
+		return r.loadBundle(ctx, scriptID, entry)
+	}
 
 	if entry.ConfigMap != "" {
 		content, err := r.loadFromConfigMap(ctx, r.namespace, entry.ConfigMap, entry.Key)
@@ -96,7 +134,14 @@ func (r *Registry) getEntry(ctx context.Context, scriptID string) (*RegistryEntr
 		return nil, fmt.Errorf("script not found in registry: %s", scriptID)
 	}
 
-	if (entry.ConfigMap == "" && entry.Secret == "") || entry.Key == "" {
+	if entry.isBundle() {
+		if entry.ConfigMap == "" && entry.Secret == "" || entry.Entrypoint == "" {
+			return nil, fmt.Errorf("invalid registry entry for %s: a bundle must have configmap or secret and entrypoint", scriptID)
+		}
+		if len(entry.Files) > 0 && entry.Archive != "" {
+			return nil, fmt.Errorf("registry entry %s has both files and archive", scriptID)
+		}
+	} else if (entry.ConfigMap == "" && entry.Secret == "") || entry.Key == "" {
 		return nil, fmt.Errorf("invalid registry entry for %s: must have configmap or secret and key", scriptID)
 	}
 
@@ -130,3 +175,66 @@ func (r *Registry) loadFromSecret(ctx context.Context, namespace, name, key stri
 	}
 	return string(contentBytes), nil
 }
+
+// loadBundle loads a bundle entry from its ConfigMap or Secret.
+func (r *Registry) loadBundle(ctx context.Context, scriptID string, entry *RegistryEntry) (string, *Source, error) {
+	var data map[string][]byte
+	name := entry.ConfigMap
+	if entry.ConfigMap != "" {
+		cm, err := r.client.CoreV1().ConfigMaps(r.namespace).Get(ctx, entry.ConfigMap, metav1.GetOptions{})
+		if err != nil {
+			return "", nil, err
+		}
+		data = make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+		for k, v := range cm.Data {
+			data[k] = []byte(v)
+		}
+		for k, v := range cm.BinaryData {
+			data[k] = v
+		}
+	} else {
+		secret, err := r.client.CoreV1().Secrets(r.namespace).Get(ctx, entry.Secret, metav1.GetOptions{})
+		if err != nil {
+			return "", nil, err
+		}
+		data, name = secret.Data, entry.Secret
+	}
+
+	var files []BundleFile
+	if entry.Archive != "" {
+		archive, ok := data[entry.Archive]
+		if !ok {
+			return "", nil, fmt.Errorf("key %q not found in %s/%s", entry.Archive, r.namespace, name)
+		}
+		var err error
+		if files, err = unpackArchive(archive); err != nil {
+			return "", nil, fmt.Errorf("bundle %s: %w", scriptID, err)
+		}
+	} else {
+		executable := make(map[string]bool, len(entry.Executable))
+		for _, p := range entry.Executable {
+			executable[p] = true
+		}
+		for p, key := range entry.Files {
+			content, ok := data[key]
+			if !ok {
+				return "", nil, fmt.Errorf("key %q not found in %s/%s", key, r.namespace, name)
+			}
+			files = append(files, BundleFile{Path: p, Content: content, Executable: executable[p]})
+		}
+	}
+
+	bundle, err := NewBundle(entry.Entrypoint, files)
+	if err != nil {
+		return "", nil, fmt.Errorf("bundle %s: %w", scriptID, err)
+	}
+	content := string(bundle.File(entry.Entrypoint).Content)
+	return content, &Source{
+		Type:      SourceRegistry,
+		Content:   content,
+		Name:      name,
+		Key:       entry.Entrypoint,
+		Namespace: r.namespace,
+		Bundle:    bundle,
+	}, nil
+}
@@ -0,0 +1,93 @@
+package security
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Validators select how commands are found in a script.
+const (
+	// ValidatorShell treats every line as a shell command line.
+	ValidatorShell = "shell"
+	// ValidatorPython, ValidatorRuby and ValidatorNode check the commands a
+	// script shells out to (os.system, subprocess, backticks, child_process).
+	ValidatorPython = "python"
+	ValidatorRuby   = "ruby"
+	ValidatorNode   = "node"
+	// ValidatorPwsh treats lines as PowerShell pipelines.
+	ValidatorPwsh = "pwsh"
+	// ValidatorNone only checks size and line count.
+	ValidatorNone = "none"
+)
+
+// Validators lists every known validator.
+var Validators = []string{ValidatorShell, ValidatorPython, ValidatorRuby, ValidatorNode, ValidatorPwsh, ValidatorNone}
+
+// shellOuts match the command string or first argv element passed to a
+// process-spawning call, per language.
+var shellOuts = map[string][]*regexp.Regexp{
+	ValidatorPython: {
+		regexp.MustCompile(`\bos\.(?:system|popen|exec[lv]p?e?|spawn[lv]p?e?)\(\s*[rbuf]?["']([^"']+)`),
+		regexp.MustCompile(`\bsubprocess\.(?:run|call|check_call|check_output|Popen|getoutput|getstatusoutput)\(\s*\[?\s*[rbuf]?["']([^"']+)`),
+	},
+	ValidatorRuby: {
+		regexp.MustCompile("`([^`]+)`"),
+		regexp.MustCompile(`%x[({\[]([^)}\]]+)`),
+		regexp.MustCompile(`\b(?:system|exec|spawn|IO\.popen|Open3\.\w+)\(?\s*["']([^"']+)`),
+	},
+	ValidatorNode: {
+		regexp.MustCompile(`\b(?:exec|execSync|execFile|execFileSync|spawn|spawnSync|fork)\(\s*["'` + "`" + `]([^"'` + "`" + `]+)`),
+	},
+	ValidatorPwsh: {
+		regexp.MustCompile(`(?i)\bStart-Process\s+(?:-FilePath\s+)?["']?([^\s"']+)`),
+	},
+}
+
+// ValidateAs checks script content with the named validator.
+func (v *ScriptValidator) ValidateAs(script, validator string) error {
+	switch validator {
+	case ValidatorShell, "":
+		return v.Validate(script)
+	case ValidatorNone:
+		return v.checkSize(script)
+	case ValidatorPython, ValidatorRuby, ValidatorNode:
+		if err := v.checkSize(script); err != nil {
+			return err
+		}
+		return v.checkCommands(extractShellOuts(script, shellOuts[validator]))
+	case ValidatorPwsh:
+		if err := v.checkSize(script); err != nil {
+			return err
+		}
+		commands := v.extractCommands(script)
+		commands = append(commands, extractShellOuts(script, shellOuts[validator])...)
+		return v.checkCommands(commands)
+	}
+	return fmt.Errorf("unknown script validator %q", validator)
+}
+
+// extractShellOuts returns the first word of every command a script passes to
+// a process-spawning call.
+func extractShellOuts(script string, patterns []*regexp.Regexp) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, re := range patterns {
+		for _, m := range re.FindAllStringSubmatch(script, -1) {
+			fields := strings.Fields(m[1])
+			if len(fields) == 0 {
+				continue
+			}
+			cmd := path.Base(fields[0])
+			if (cmd == "sudo" || cmd == "su") && len(fields) > 1 {
+				cmd = path.Base(fields[1])
+			}
+			if !seen[cmd] {
+				seen[cmd] = true
+				out = append(out, cmd)
+			}
+		}
+	}
+	return out
+}
@@ -32,6 +32,14 @@ func NewScriptValidator(blocked, allowed []string, maxSize, maxLines int) *Scrip
 
 // Validate checks script content.
 func (v *ScriptValidator) Validate(script string) error {
+	if err := v.checkSize(script); err != nil {
+		return err
+	}
+	return v.checkCommands(v.extractCommands(script))
+}
+
+// checkSize enforces the size and line limits.
+func (v *ScriptValidator) checkSize(script string) error {
 	if len(script) > v.maxSize {
 		return fmt.Errorf("script too large: %d bytes (max: %d)", len(script), v.maxSize)
 	}
@@ -40,9 +48,11 @@ func (v *ScriptValidator) Validate(script string) error {
 	if len(lines) > v.maxLines {
 		return fmt.Errorf("script too long: %d lines (max: %d)", len(lines), v.maxLines)
 	}
+	return nil
+}
 
-	commands := v.extractCommands(script)
-
+// checkCommands applies the blocked and allowed command lists.
+func (v *ScriptValidator) checkCommands(commands []string) error {
 	for _, cmd := range commands {
 		for _, blocked := range v.blockedCommands {
 			if v.matchesCommand(cmd, blocked) {
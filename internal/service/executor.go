@@ -2,7 +2,7 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	"google.golang.org/grpc"
@@ -12,7 +12,10 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	executorv1 "github.com/rakeshavasarala/script-executor/gen/go/proto/executor/v1"
+	"github.com/rakeshavasarala/script-executor/internal/artifact"
 	"github.com/rakeshavasarala/script-executor/internal/execution"
+	"github.com/rakeshavasarala/script-executor/internal/logstore"
+	"github.com/rakeshavasarala/script-executor/internal/record"
 )
 
 // ScriptExecutor implements the Executor gRPC service.
@@ -34,46 +37,95 @@ func (s *ScriptExecutor) Execute(ctx context.Context, req *executorv1.ExecuteReq
 	return s.manager.Execute(ctx, req)
 }
 
-// ExecuteStream streams progress for script.run execution.
-func (s *ScriptExecutor) ExecuteStream(req *executorv1.ExecuteRequest, stream grpc.ServerStreamingServer[executorv1.ExecuteProgress]) error {
+// ExecuteAsync submits a script.run step and returns its execution handle.
+func (s *ScriptExecutor) ExecuteAsync(ctx context.Context, req *executorv1.ExecuteRequest) (*executorv1.Execution, error) {
 	if req.StepType != "script.run" {
-		return status.Errorf(codes.Unimplemented, "step type %s does not support streaming", req.StepType)
+		return nil, status.Errorf(codes.Unimplemented, "unknown step type: %s", req.StepType)
 	}
+	exec, err := s.manager.ExecuteAsync(ctx, req)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "submit execution: %v", err)
+	}
+	return exec, nil
+}
 
-	// Send starting
-	stream.Send(&executorv1.ExecuteProgress{
-		Stage:           executorv1.ExecuteProgress_STAGE_STARTING,
-		PercentComplete: 0,
-		Message:         "Validating and preparing script execution...",
-		Timestamp:       timestamppb.Now(),
-	})
+// GetExecution returns the state of a submitted execution.
+func (s *ScriptExecutor) GetExecution(ctx context.Context, req *executorv1.GetExecutionRequest) (*executorv1.Execution, error) {
+	if req.ExecutionId == "" {
+		return nil, status.Error(codes.InvalidArgument, "execution_id is required")
+	}
+	exec, err := s.manager.GetExecution(ctx, req.ExecutionId)
+	if err != nil {
+		return nil, executionError(req.ExecutionId, err)
+	}
+	return exec, nil
+}
 
-	// Execute (same as Execute but we stream progress)
-	startTime := time.Now()
-	resp, err := s.manager.Execute(stream.Context(), req)
+// CancelExecution cancels a running execution.
+func (s *ScriptExecutor) CancelExecution(ctx context.Context, req *executorv1.CancelExecutionRequest) (*executorv1.Execution, error) {
+	if req.ExecutionId == "" {
+		return nil, status.Error(codes.InvalidArgument, "execution_id is required")
+	}
+	exec, err := s.manager.CancelExecution(ctx, req.ExecutionId, req.GracePeriod.AsDuration(), req.Reason)
 	if err != nil {
-		return err
+		return nil, executionError(req.ExecutionId, err)
+	}
+	return exec, nil
+}
+
+func executionError(executionID string, err error) error {
+	if errors.Is(err, record.ErrNotFound) {
+		return status.Errorf(codes.NotFound, "execution %s not found", executionID)
 	}
+	return status.Errorf(codes.Internal, "execution %s: %v", executionID, err)
+}
 
-	// Send running
-	stream.Send(&executorv1.ExecuteProgress{
-		Stage:           executorv1.ExecuteProgress_STAGE_RUNNING,
-		PercentComplete: 50,
-		Message:         "Script execution in progress...",
-		Timestamp:       timestamppb.Now(),
-	})
+// GetLog streams the stored full log of an execution with truncated output.
+func (s *ScriptExecutor) GetLog(req *executorv1.GetLogRequest, stream grpc.ServerStreamingServer[executorv1.LogChunk]) error {
+	if req.LogRef == "" {
+		return status.Error(codes.InvalidArgument, "log_ref is required")
+	}
+	switch req.Stream {
+	case "", "stdout", "stderr":
+	default:
+		return status.Errorf(codes.InvalidArgument, "invalid stream %q", req.Stream)
+	}
+	err := s.manager.GetLog(stream.Context(), req.LogRef, req.Stream, stream.Send)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, logstore.ErrNotFound):
+		return status.Errorf(codes.NotFound, "log %s not found", req.LogRef)
+	case errors.Is(err, execution.ErrLogStoreDisabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Errorf(codes.Internal, "read log %s: %v", req.LogRef, err)
+}
 
-	// The manager already waits for completion, so we just send the result
-	stream.Send(&executorv1.ExecuteProgress{
-		Stage:           executorv1.ExecuteProgress_STAGE_DONE,
-		PercentComplete: 100,
-		Message:         fmt.Sprintf("Execution completed with status %s", resp.Status),
-		Timestamp:       timestamppb.Now(),
-		Result:          resp,
-	})
+// GetArtifact streams a stored artifact.
+func (s *ScriptExecutor) GetArtifact(req *executorv1.GetArtifactRequest, stream grpc.ServerStreamingServer[executorv1.ArtifactChunk]) error {
+	if req.Ref == "" {
+		return status.Error(codes.InvalidArgument, "ref is required")
+	}
+	err := s.manager.GetArtifact(stream.Context(), req.Ref, stream.Send)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, artifact.ErrNotFound):
+		return status.Errorf(codes.NotFound, "artifact %s not found", req.Ref)
+	case errors.Is(err, execution.ErrArtifactStoreDisabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Errorf(codes.Internal, "read artifact %s: %v", req.Ref, err)
+}
 
-	_ = startTime
-	return nil
+// ExecuteStream streams progress for script.run execution: pod phase
+// transitions, live log output and heartbeats, then the final result.
+func (s *ScriptExecutor) ExecuteStream(req *executorv1.ExecuteRequest, stream grpc.ServerStreamingServer[executorv1.ExecuteProgress]) error {
+	if req.StepType != "script.run" {
+		return status.Errorf(codes.Unimplemented, "step type %s does not support streaming", req.StepType)
+	}
+	return s.manager.ExecuteStream(stream.Context(), req, stream.Send)
 }
 
 // Describe returns executor capabilities.
@@ -86,13 +138,15 @@ func (s *ScriptExecutor) Describe(ctx context.Context, req *executorv1.DescribeR
 				Type:               "script.run",
 				SupportsStreaming:  true,
 				TypicalDuration:    durationpb.New(5 * time.Minute),
-				Description:        "Execute shell, Python, or Ruby scripts in a secure Kubernetes Job",
+				Description:        "Execute shell, Python, Ruby, Node.js or PowerShell scripts in a secure Kubernetes Job",
 				RequiredParameters: []string{},
 				OptionalParameters: []string{
-					"inline_script", "script_from_configmap", "script_from_secret", "script_path", "script_id",
-					"image", "image_ref", "interpreter", "args", "env", "timeout",
+					"inline_script", "script_from_configmap", "script_from_secret", "script_path", "script_bundle", "script_id",
+					"image", "image_ref", "language", "interpreter", "args", "stdin", "env", "timeout",
+					"scheduling_timeout", "image_pull_timeout", "on_disconnect",
+					"output_format", "extract", "success_criteria", "artifacts", "files", "inputs", "input_schema", "template",
 					"env_from_secret", "env_from_configmap", "secret_env_all", "configmap_env_all",
-					"volumes_from_secret", "volumes_from_configmap", "node_selector", "resources",
+					"volumes_from_secret", "volumes_from_configmap", "node_selector", "tolerations", "affinity", "topology_spread_constraints", "resources", "profile", "sidecars", "tools", "workspace", "cache",
 					"approval_required", "approvers",
 				},
 			},